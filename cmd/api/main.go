@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/basedalex/effective-mobile-test/internal/api"
 	"github.com/basedalex/effective-mobile-test/internal/config"
@@ -25,18 +26,70 @@ func main() {
 
 	cfg := config.New()
 
+	// ./cars.elf migrate [up|down|status|redo] runs migrations and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		command := "up"
+		if len(os.Args) > 2 {
+			command = os.Args[2]
+		}
+
+		err := db.Migrate(ctx, cfg.Env.PGDSN, command)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return
+	}
+
+	if cfg.Env.AutoMigrate {
+		err := db.Migrate(ctx, cfg.Env.PGDSN, "up")
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
 	database, err := db.NewPostgres(ctx, cfg.Env.PGDSN)
 	if err != nil {
 		log.Panic(err)
 	}
 
-	apiClient := api.New(cfg.Env.APIHost, cfg.Env.APIPort)
+	if cfg.Env.PurgeRetention > 0 {
+		go database.RunPurge(ctx,
+			time.Duration(max(cfg.Env.PurgeInterval, 1))*time.Second,
+			time.Duration(cfg.Env.PurgeRetention)*24*time.Hour)
+	}
+
+	var infoCache api.Cache
+
+	switch cfg.Env.InfoCache {
+	case "memory":
+		infoCache = api.NewLRUCache(cfg.Env.InfoCacheSize)
+	case "postgres":
+		pgCache := db.NewInfoCache(database)
+		go pgCache.RunCleanup(ctx, time.Duration(max(cfg.Env.InfoCacheCleanupInterval, 1))*time.Second)
+
+		infoCache = pgCache
+	case "none", "":
+	default:
+		log.Panicf("unknown INFO_CACHE %q, expected memory, postgres or none", cfg.Env.InfoCache)
+	}
+
+	apiClient := api.New(cfg.Env.APIHost, cfg.Env.APIPort, api.Options{
+		Timeout:          time.Duration(cfg.Env.APITimeout) * time.Second,
+		MaxRetries:       cfg.Env.APIMaxRetries,
+		BackoffBase:      time.Duration(cfg.Env.APIBackoffBase) * time.Millisecond,
+		BackoffMax:       time.Duration(cfg.Env.APIBackoffMax) * time.Millisecond,
+		BreakerThreshold: cfg.Env.APIBreakerThreshold,
+		BreakerCooldown:  time.Duration(cfg.Env.APIBreakerCooldown) * time.Second,
+		Cache:            infoCache,
+		CacheTTL:         time.Duration(cfg.Env.InfoCacheTTL) * time.Second,
+		NegativeCacheTTL: time.Duration(cfg.Env.InfoCacheNegativeTTL) * time.Second,
+	})
 
 	log.Info("connected to db")
 	log.Info("connecting to ", cfg.Env.Port)
-	err = router.NewServer(ctx, cfg, database, apiClient)
+	err = router.NewServer(ctx, cfg, database, database, database, apiClient)
 	if err != nil {
 		log.Panic(err)
 	}
-	
-}
\ No newline at end of file
+}
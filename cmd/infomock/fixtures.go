@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/basedalex/effective-mobile-test/internal/api"
+)
+
+// loadFixtures reads cars from a JSON array of api.Car or from a CSV file with
+// the header regNum,mark,model,year,name,surname,patronymic.
+func loadFixtures(path string) (map[string]api.Car, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open fixtures: %w", err)
+	}
+	defer f.Close()
+
+	var cars []api.Car
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.NewDecoder(f).Decode(&cars)
+	case ".csv":
+		cars, err = readCSV(f)
+	default:
+		err = errors.New("unsupported file extension, expected .json or .csv")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("read fixtures %s: %w", path, err)
+	}
+
+	res := make(map[string]api.Car, len(cars))
+
+	for _, c := range cars {
+		res[c.RegNum] = c
+	}
+
+	return res, nil
+}
+
+func readCSV(r io.Reader) ([]api.Car, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, h := range header {
+		columns[strings.TrimSpace(h)] = i
+	}
+
+	if _, ok := columns["regNum"]; !ok {
+		return nil, errors.New("regNum column is missing")
+	}
+
+	var cars []api.Car
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		get := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		car := api.Car{
+			RegNum: get("regNum"),
+			Mark:   get("mark"),
+			Model:  get("model"),
+			Owner: api.People{
+				Name:       get("name"),
+				Surname:    get("surname"),
+				Patronymic: get("patronymic"),
+			},
+		}
+
+		if y := get("year"); y != "" {
+			car.Year, err = strconv.Atoi(y)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad year %q", car.RegNum, y)
+			}
+		}
+
+		cars = append(cars, car)
+	}
+
+	return cars, nil
+}
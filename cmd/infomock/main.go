@@ -0,0 +1,171 @@
+// Command infomock is a local implementation of the external car info API
+// described in api.yaml. It serves GET /info?regNum= from a fixture file and
+// can inject latency, 400s, 500s and malformed JSON to exercise the client.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/api"
+	log "github.com/sirupsen/logrus"
+)
+
+type settings struct {
+	latency     time.Duration
+	jitter      time.Duration
+	badRequest  float64
+	serverError float64
+	malformed   float64
+	generate    bool
+}
+
+func main() {
+	addr := flag.String("addr", ":3000", "listen address")
+	fixtures := flag.String("fixtures", "", "path to a .json or .csv file with cars")
+	latency := flag.Duration("latency", 0, "delay added to every response")
+	jitter := flag.Duration("jitter", 0, "random extra delay up to this value")
+	badRequest := flag.Float64("bad-request-rate", 0, "share of requests answered with 400, 0..1")
+	serverError := flag.Float64("server-error-rate", 0, "share of requests answered with 500, 0..1")
+	malformed := flag.Float64("malformed-rate", 0, "share of requests answered with malformed JSON, 0..1")
+	generate := flag.Bool("generate", false, "generate a car for regNums missing from fixtures instead of 400")
+	flag.Parse()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	cars := map[string]api.Car{}
+
+	if *fixtures != "" {
+		var err error
+
+		cars, err = loadFixtures(*fixtures)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	log.Infof("loaded %d cars", len(cars))
+
+	s := settings{
+		latency:     *latency,
+		jitter:      *jitter,
+		badRequest:  *badRequest,
+		serverError: *serverError,
+		malformed:   *malformed,
+		generate:    *generate,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /info", infoHandler(cars, s))
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 3 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Warn(err)
+		}
+	}()
+
+	log.Info("info mock listening on ", *addr)
+
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
+
+func infoHandler(cars map[string]api.Car, s settings) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		delay := s.latency
+		if s.jitter > 0 {
+			delay += rand.N(s.jitter)
+		}
+
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		regNum := r.URL.Query().Get("regNum")
+		log.Debug("info requested for ", regNum)
+
+		if regNum == "" || hit(s.badRequest) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		if hit(s.serverError) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		car, ok := cars[regNum]
+		if !ok {
+			if !s.generate {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			car = generateCar(regNum)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		if hit(s.malformed) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(`{"regNum": "` + regNum + `", "mark": `))
+
+			return
+		}
+
+		err := json.NewEncoder(w).Encode(car)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+}
+
+func hit(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+var (
+	marks       = []string{"Lada", "Kia", "Hyundai", "Toyota", "Skoda"}
+	models      = []string{"Vesta", "Rio", "Solaris", "Camry", "Octavia"}
+	names       = []string{"Ivan", "Petr", "Sergey", "Anna", "Olga"}
+	surnames    = []string{"Ivanov", "Petrov", "Sidorov", "Smirnov", "Kuznetsov"}
+	patronymics = []string{"Ivanovich", "Petrovich", "Sergeevich", ""}
+)
+
+func generateCar(regNum string) api.Car {
+	return api.Car{
+		RegNum: regNum,
+		Mark:   marks[rand.IntN(len(marks))],
+		Model:  models[rand.IntN(len(models))],
+		Year:   1990 + rand.IntN(35),
+		Owner: api.People{
+			Name:       names[rand.IntN(len(names))],
+			Surname:    surnames[rand.IntN(len(surnames))],
+			Patronymic: patronymics[rand.IntN(len(patronymics))],
+		},
+	}
+}
@@ -15,6 +15,123 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/api/v1/audit": {
+            "get": {
+                "description": "changes of cars and owners, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "GetAudit",
+                "operationId": "get-audit",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "car or owner",
+                        "name": "entity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "id of the car or owner",
+                        "name": "entityId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "create, update, delete, restore, transfer, merge or purge, repeat for several",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "actor",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "request id",
+                        "name": "requestId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD, inclusive",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD, exclusive",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.AuditEvent"
+                                            }
+                                        },
+                                        "pagination": {
+                                            "$ref": "#/definitions/router.Pagination"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/car": {
             "get": {
                 "description": "get car",
@@ -30,10 +147,180 @@ const docTemplate = `{
                 "summary": "GetCar",
                 "operationId": "get-car",
                 "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "registration number, repeat for several",
+                        "name": "regNum",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "region code, matches all codes of its subject, repeat for several",
+                        "name": "region",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "mark, repeat for several",
+                        "name": "mark",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "model, repeat for several",
+                        "name": "model",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "case-insensitive model prefix",
+                        "name": "modelPrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "case-insensitive model substring",
+                        "name": "modelContains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "year, repeat for several",
+                        "name": "year",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "minimal year",
+                        "name": "yearFrom",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "maximal year",
+                        "name": "yearTo",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "owner name, repeat for several",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "owner surname, repeat for several",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "case-insensitive owner surname prefix",
+                        "name": "surnamePrefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "case-insensitive owner surname substring",
+                        "name": "surnameContains",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "owner patronymic, repeat for several",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "owner id",
+                        "name": "ownerId",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD",
+                        "name": "createdAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD",
+                        "name": "createdBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD",
+                        "name": "updatedAfter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC 3339 time or YYYY-MM-DD",
+                        "name": "updatedBefore",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "comma separated sort fields, minus for descending, e.g. -year,mark",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "list soft deleted cars too",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset, ignored when cursor is set",
+                        "name": "offset",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "search options",
-                        "name": "q",
+                        "description": "nextCursor of the previous page for keyset pagination",
+                        "name": "cursor",
                         "in": "query"
                     }
                 ],
@@ -41,7 +328,41 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "intgeger"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.Car"
+                                            }
+                                        },
+                                        "pagination": {
+                                            "$ref": "#/definitions/router.Pagination"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "RFC 5988 links to the first, prev, next and last pages"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "number of cars matching the filters"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
                         }
                     },
                     "500": {
@@ -59,7 +380,7 @@ const docTemplate = `{
                 }
             },
             "post": {
-                "description": "create car",
+                "description": "create cars, the response lists the outcome for every regNum",
                 "consumes": [
                     "application/json"
                 ],
@@ -80,13 +401,55 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/router.payload"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "no-cache to skip cached info API answers",
+                        "name": "Cache-Control",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Created",
+                        "description": "all cars stored",
                         "schema": {
-                            "type": "integer"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/router.createResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "207": {
+                        "description": "some cars stored",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/router.createResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
@@ -95,10 +458,25 @@ const docTemplate = `{
                             "$ref": "#/definitions/router.HTTPResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "422": {
+                        "description": "no cars stored",
                         "schema": {
-                            "$ref": "#/definitions/router.HTTPResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/router.createResult"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "default": {
@@ -110,9 +488,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/car/{id}": {
-            "delete": {
-                "description": "delete car",
+        "/api/v1/car/by-reg-num/{regNum}": {
+            "put": {
+                "description": "replace the whole car with the regNum like PUT /api/v1/car/{id}. When\nPUT_CREATE is on a regNum which isn't in the catalogue is created as a new car,\nIf-None-Match: * makes sure an existing car isn't replaced",
                 "consumes": [
                     "application/json"
                 ],
@@ -122,22 +500,103 @@ const docTemplate = `{
                 "tags": [
                     "car"
                 ],
-                "summary": "DeleteCar",
-                "operationId": "delete-car",
+                "summary": "ReplaceCarByRegNum",
+                "operationId": "replace-car-by-reg-num",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Car ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "registration number, the same as in the body",
+                        "name": "regNum",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "the whole car",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/router.replacePayload"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the replacement is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "* to only create the car",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "car replaced",
                         "schema": {
-                            "type": "integer"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "201": {
+                        "description": "car created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
+                        },
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "the new car"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "the car was created meanwhile",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "the car was changed since the given version or already exists",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
                         }
                     },
                     "500": {
@@ -153,20 +612,19 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "update car",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/v1/car/{id}": {
+            "get": {
+                "description": "get a single car with its owner",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "car"
                 ],
-                "summary": "UpdateCar",
-                "operationId": "update-car",
+                "summary": "GetCarByID",
+                "operationId": "get-car-by-id",
                 "parameters": [
                     {
                         "type": "integer",
@@ -176,27 +634,58 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "update options",
-                        "name": "request",
-                        "in": "body",
-                        "schema": {
-                            "$ref": "#/definitions/router.updatePayload"
-                        }
+                        "type": "boolean",
+                        "description": "return the car even if it is soft deleted",
+                        "name": "includeDeleted",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Last-Modified of the cached copy",
+                        "name": "If-Modified-Since",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "integer"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
+                    "304": {
+                        "description": "Not Modified"
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
                             "$ref": "#/definitions/router.HTTPResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -210,50 +699,1572 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "router.HTTPResponse": {
-            "type": "object",
-            "properties": {
-                "data": {},
+            },
+            "put": {
+                "description": "replace the whole car, owner included. regNum, mark, model, owner.name and\nowner.surname are required, an absent year or owner.patronymic is cleared.\nA PUT mirrors a whole record, so a different owner is taken as the car changing\nhands: it is recorded and audited as a transfer, PATCH corrects owner data instead",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "ReplaceCar",
+                "operationId": "replace-car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "the whole car",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/router.replacePayload"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the replacement is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "regNum is taken",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "the car was changed since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "soft delete car, it can be restored until the retention job purges it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "DeleteCar",
+                "operationId": "delete-car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update car. With application/json empty fields are left untouched, with\napplication/merge-patch+json (RFC 7396) absent fields are left untouched and null\nclears year and patronymic, application/json-patch+json (RFC 6902) operations\napply to the flat document of updatePayload fields, e.g. {\"op\": \"remove\", \"path\": \"/year\"}",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json",
+                    "application/json-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "UpdateCar",
+                "operationId": "update-car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/router.updatePayload"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the change is based on",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "integer"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "regNum is taken or a JSON Patch test operation failed",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "the car was changed since the given version",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/car/{id}/history": {
+            "get": {
+                "description": "audit log of the car, newest first, also for deleted cars",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "GetCarHistory",
+                "operationId": "get-car-history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "create, update, delete, restore, transfer, merge or purge, repeat for several",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.AuditEvent"
+                                            }
+                                        },
+                                        "pagination": {
+                                            "$ref": "#/definitions/router.Pagination"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/car/{id}/owners": {
+            "get": {
+                "description": "chain of the car's owners, newest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "GetCarOwners",
+                "operationId": "get-car-owners",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "only the owner at this moment, RFC 3339 or YYYY-MM-DD",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.Ownership"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/car/{id}/restore": {
+            "post": {
+                "description": "restore a soft deleted car",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "RestoreCar",
+                "operationId": "restore-car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "the plate was taken by another car",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/car/{id}/transfer": {
+            "post": {
+                "description": "assign the car to a new or existing owner and record it in the ownership history",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "TransferCar",
+                "operationId": "transfer-car",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Car ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "new owner",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/router.transferPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.Car"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/health": {
+            "get": {
+                "description": "service health and info API circuit breaker state",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health",
+                "operationId": "health",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/router.healthResponse"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/info-cache/{regNum}": {
+            "delete": {
+                "description": "drop the cached info API answer for a regNum",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "car"
+                ],
+                "summary": "InvalidateInfoCache",
+                "operationId": "invalidate-info-cache",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "registration number",
+                        "name": "regNum",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/owners": {
+            "get": {
+                "description": "list owners filtered by name, surname and patronymic",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "GetOwners",
+                "operationId": "get-owners",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "surname",
+                        "name": "surname",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "patronymic",
+                        "name": "patronymic",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.People"
+                                            }
+                                        },
+                                        "pagination": {
+                                            "$ref": "#/definitions/router.Pagination"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "create owner",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "CreateOwner",
+                "operationId": "create-owner",
+                "parameters": [
+                    {
+                        "description": "owner",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/router.newOwnerPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.People"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/owners/{id}": {
+            "get": {
+                "description": "get owner",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "GetOwnerByID",
+                "operationId": "get-owner-by-id",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.People"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "delete an owner who has never owned a car",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "DeleteOwner",
+                "operationId": "delete-owner",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "update owner, the change applies to all of their cars",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "UpdateOwner",
+                "operationId": "update-owner",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update options",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/router.ownerPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.People"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/owners/{id}/cars": {
+            "get": {
+                "description": "list cars of the owner",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "GetOwnerCars",
+                "operationId": "get-owner-cars",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "array",
+                                            "items": {
+                                                "$ref": "#/definitions/db.Car"
+                                            }
+                                        },
+                                        "pagination": {
+                                            "$ref": "#/definitions/router.Pagination"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/owners/{id}/merge": {
+            "post": {
+                "description": "move cars of the listed owners to this owner and delete them",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "owner"
+                ],
+                "summary": "MergeOwners",
+                "operationId": "merge-owners",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Owner ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "owners to merge",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/router.mergePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/router.HTTPResponse"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "$ref": "#/definitions/db.People"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    },
+                    "default": {
+                        "description": "",
+                        "schema": {
+                            "$ref": "#/definitions/router.HTTPResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BreakerStats": {
+            "type": "object",
+            "properties": {
+                "consecutiveFailures": {
+                    "type": "integer"
+                },
+                "openedAt": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "db.AuditEvent": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "after": {
+                    "type": "object"
+                },
+                "before": {
+                    "type": "object"
+                },
+                "entity": {
+                    "type": "string"
+                },
+                "entityId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "occurredAt": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                }
+            }
+        },
+        "db.Car": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "DeletedAt is set for soft deleted cars",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mark": {
+                    "type": "string"
+                },
+                "model": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/db.People"
+                },
+                "regNum": {
+                    "type": "string"
+                },
+                "region": {
+                    "description": "Region is the region code of the plate, empty for unrecognized plates",
+                    "type": "string"
+                },
+                "regionName": {
+                    "type": "string"
+                },
+                "updatedAat": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Version is bumped by every change, UpdateCar checks it when it is set",
+                    "type": "integer"
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "db.ConflictPolicy": {
+            "type": "string",
+            "enum": [
+                "error",
+                "skip",
+                "overwrite"
+            ],
+            "x-enum-varnames": [
+                "ConflictError",
+                "ConflictSkip",
+                "ConflictOverwrite"
+            ]
+        },
+        "db.Ownership": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "owner": {
+                    "$ref": "#/definitions/db.People"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "db.People": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "patronymic": {
+                    "type": "string"
+                },
+                "surname": {
+                    "type": "string"
+                }
+            }
+        },
+        "errs.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "router.HTTPResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields lists every invalid field of a validation error",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/errs.FieldError"
+                    }
+                },
+                "pagination": {
+                    "$ref": "#/definitions/router.Pagination"
+                }
+            }
+        },
+        "router.Pagination": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "type": "string"
+                },
+                "nextCursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "router.createResult": {
+            "type": "object",
+            "properties": {
+                "carId": {
+                    "type": "integer"
+                },
                 "error": {
                     "type": "string"
+                },
+                "regNum": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/router.createStatus"
+                },
+                "upstreamStatus": {
+                    "type": "integer"
+                }
+            }
+        },
+        "router.createStatus": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "skipped",
+                "enrichment_failed",
+                "duplicate",
+                "db_error"
+            ],
+            "x-enum-varnames": [
+                "statusCreated",
+                "statusUpdated",
+                "statusSkipped",
+                "statusEnrichmentFailed",
+                "statusDuplicate",
+                "statusDBError"
+            ]
+        },
+        "router.healthResponse": {
+            "type": "object",
+            "properties": {
+                "infoApi": {
+                    "$ref": "#/definitions/api.BreakerStats"
+                }
+            }
+        },
+        "router.mergePayload": {
+            "type": "object",
+            "properties": {
+                "ownerIds": {
+                    "description": "OwnerIDs are merged into the owner from the path and deleted",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "router.newOwnerPayload": {
+            "type": "object",
+            "required": [
+                "name",
+                "surname"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "patronymic": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "surname": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "router.ownerPayload": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "patronymic": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "surname": {
+                    "type": "string",
+                    "maxLength": 100
                 }
             }
         },
         "router.payload": {
             "type": "object",
+            "required": [
+                "regNums"
+            ],
             "properties": {
+                "onConflict": {
+                    "description": "OnConflict is what to do with regNums which are already in the catalogue:\nerror (default), skip or overwrite",
+                    "enum": [
+                        "error",
+                        "skip",
+                        "overwrite"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/db.ConflictPolicy"
+                        }
+                    ]
+                },
                 "regNums": {
                     "type": "array",
+                    "maxItems": 1000,
                     "items": {
                         "type": "string"
                     }
                 }
             }
         },
-        "router.updatePayload": {
+        "router.replacePayload": {
             "type": "object",
+            "required": [
+                "mark",
+                "model",
+                "regNum"
+            ],
             "properties": {
                 "mark": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
                 },
                 "model": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "owner": {
+                    "type": "object",
+                    "required": [
+                        "name",
+                        "surname"
+                    ],
+                    "properties": {
+                        "name": {
+                            "type": "string",
+                            "maxLength": 100
+                        },
+                        "patronymic": {
+                            "type": "string",
+                            "maxLength": 100
+                        },
+                        "surname": {
+                            "type": "string",
+                            "maxLength": 100
+                        }
+                    }
+                },
+                "regNum": {
                     "type": "string"
                 },
-                "name": {
+                "version": {
+                    "description": "Version the replacement is based on, the same as sending its ETag in If-Match",
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "year": {
+                    "type": "integer"
+                }
+            }
+        },
+        "router.transferPayload": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "description": "Date of the transfer, now if omitted",
                     "type": "string"
                 },
+                "owner": {
+                    "$ref": "#/definitions/router.newOwnerPayload"
+                },
+                "ownerId": {
+                    "description": "OwnerID of an existing owner, or Owner to find or create one",
+                    "type": "integer"
+                }
+            }
+        },
+        "router.updatePayload": {
+            "type": "object",
+            "properties": {
+                "mark": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "model": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
                 "patronymic": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
                 },
                 "regNum": {
                     "type": "string"
                 },
                 "surname": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "version": {
+                    "description": "Version the change is based on, the same as sending its ETag in If-Match",
+                    "type": "integer",
+                    "minimum": 1
                 },
                 "year": {
                     "type": "integer"
@@ -3,10 +3,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand/v2"
 	"net"
 	"net/http"
+	"net/url"
+	"time"
 
+	"github.com/basedalex/effective-mobile-test/internal/errs"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,46 +29,244 @@ type People struct {
 	Patronymic string `json:"patronymic"`
 }
 
+// StatusError is returned by GetInfo when the info service responds with
+// anything other than 200 OK.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("incorrect status code: %d", e.StatusCode)
+}
+
+// Unwrap classifies 4xx answers as validation errors and the rest as the info
+// service being unavailable.
+func (e *StatusError) Unwrap() error {
+	if e.StatusCode >= http.StatusBadRequest && e.StatusCode < http.StatusInternalServerError {
+		return errs.ErrValidation
+	}
+
+	return errs.ErrUpstreamUnavailable
+}
+
+// Options configures timeouts, retries and the circuit breaker of the Client.
+type Options struct {
+	// Timeout limits a single http request to the info service.
+	Timeout time.Duration
+	// MaxRetries is the number of extra attempts made on network errors and 5xx responses.
+	MaxRetries  int
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	// BreakerThreshold is the number of consecutive failed calls which opens
+	// the breaker, zero disables it.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// Cache is consulted before calling the info service, nil disables caching.
+	// Successful answers are kept for CacheTTL, 400 answers for NegativeCacheTTL.
+	Cache            Cache
+	CacheTTL         time.Duration
+	NegativeCacheTTL time.Duration
+}
+
 type Client struct {
-	host string
-	port string
+	host    string
+	port    string
+	opts    Options
+	http    *http.Client
+	breaker *breaker
+}
+
+func New(host, port string, opts Options) *Client {
+	return &Client{
+		host:    host,
+		port:    port,
+		opts:    opts,
+		http:    &http.Client{Timeout: opts.Timeout},
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+	}
+}
+
+// BreakerStats reports the current state of the info API circuit breaker.
+func (c *Client) BreakerStats() BreakerStats {
+	return c.breaker.stats()
 }
 
-func New(host, port string) Client {
-	return Client{
-		host: host,
-		port: port,
+// InvalidateCache drops the cached info API answer for regNum.
+func (c *Client) InvalidateCache(ctx context.Context, regNum string) error {
+	if c.opts.Cache == nil {
+		return nil
+	}
+
+	err := c.opts.Cache.Delete(ctx, regNum)
+	if err != nil {
+		return fmt.Errorf("info cache: %w", err)
 	}
+
+	return nil
 }
 
+// GetInfo returns car info for regNum from the cache or the external API.
 func (c *Client) GetInfo(ctx context.Context, regNum string) (Car, error) {
+	if c.opts.Cache != nil && !cacheBypassed(ctx) {
+		e, ok, err := c.opts.Cache.Get(ctx, regNum)
+		if err != nil {
+			log.Warnf("%s: info cache: %s", regNum, err)
+		}
+
+		if ok {
+			log.Debugf("%s: info cache hit", regNum)
+
+			if e.NotFound {
+				return Car{}, &StatusError{StatusCode: http.StatusBadRequest}
+			}
+
+			return e.Car, nil
+		}
+	}
+
+	car, err := c.fetchInfo(ctx, regNum)
+
+	if c.opts.Cache != nil {
+		c.storeCache(ctx, regNum, car, err)
+	}
+
+	return car, err
+}
+
+func (c *Client) storeCache(ctx context.Context, regNum string, car Car, err error) {
+	var e CacheEntry
+
+	var statusErr *StatusError
+
+	switch {
+	case err == nil:
+		e = CacheEntry{Car: car, ExpiresAt: time.Now().Add(c.opts.CacheTTL)}
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest:
+		e = CacheEntry{NotFound: true, ExpiresAt: time.Now().Add(c.opts.NegativeCacheTTL)}
+	default:
+		return
+	}
+
+	if !e.ExpiresAt.After(time.Now()) {
+		return
+	}
+
+	if err := c.opts.Cache.Set(ctx, regNum, e); err != nil {
+		log.Warnf("%s: info cache: %s", regNum, err)
+	}
+}
+
+// fetchInfo calls the external API. Network errors and 5xx responses are
+// retried with exponential backoff and jitter, 4xx responses are not.
+func (c *Client) fetchInfo(ctx context.Context, regNum string) (Car, error) {
+	if !c.breaker.allow() {
+		return Car{}, ErrCircuitOpen
+	}
+
+	var (
+		car Car
+		err error
+	)
+
+	for attempt := 0; ; attempt++ {
+		car, err = c.getInfo(ctx, regNum)
+		if err == nil || !retryable(ctx, err) || attempt >= c.opts.MaxRetries {
+			break
+		}
+
+		wait := c.backoff(attempt)
+		log.Debugf("%s: attempt %d failed, retrying in %s: %s", regNum, attempt+1, wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	// attempts cut short by the caller's deadline timed out like any other, only
+	// a canceled call tells nothing about the upstream
+	switch {
+	case err == nil:
+		c.breaker.success()
+	case errors.Is(ctx.Err(), context.Canceled):
+		c.breaker.release()
+	case transient(err) || errors.Is(ctx.Err(), context.DeadlineExceeded):
+		c.breaker.failure()
+	default:
+		// 4xx and malformed bodies mean the upstream is up
+		c.breaker.success()
+	}
+
+	return car, err
+}
+
+func (c *Client) getInfo(ctx context.Context, regNum string) (Car, error) {
 	addr := net.JoinHostPort(c.host, c.port)
 
-	url := fmt.Sprintf("http://%s/info?regNum=%s", addr, regNum)
+	u := fmt.Sprintf("http://%s/info?%s", addr, url.Values{"regNum": {regNum}}.Encode())
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return Car{},fmt.Errorf("create request: %w", err)
+		return Car{}, fmt.Errorf("create request: %w", err)
 	}
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := c.http.Do(req)
 	if err != nil {
-		return Car{},fmt.Errorf("get response: %w", err)
+		return Car{}, errs.Unavailable("info api is unavailable", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return Car{}, fmt.Errorf("incorrect status code: %d", res.StatusCode)
+		return Car{}, &StatusError{StatusCode: res.StatusCode}
 	}
 
 	var car Car
 
 	err = json.NewDecoder(res.Body).Decode(&car)
 	if err != nil {
-		return Car{},fmt.Errorf("json decode: %w", err)
+		return Car{}, errs.Unavailable("info api returned malformed response", err)
 	}
 	// log which car we got from API
 	log.Info(car)
 
 	return car, nil
-}
\ No newline at end of file
+}
+
+// backoff returns a random delay in [d/2, d) where d grows exponentially with
+// the attempt number and is capped by BackoffMax.
+func (c *Client) backoff(attempt int) time.Duration {
+	d := c.opts.BackoffBase << attempt
+	if d <= 0 || (c.opts.BackoffMax > 0 && d > c.opts.BackoffMax) {
+		d = c.opts.BackoffMax
+	}
+
+	if d <= 1 {
+		return d
+	}
+
+	return d/2 + rand.N(d/2)
+}
+
+// retryable reports whether err is transient and the caller still waits.
+func retryable(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && transient(err)
+}
+
+// transient reports whether err is a network error or a 5xx response.
+func transient(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
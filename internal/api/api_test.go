@@ -0,0 +1,167 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBackoffBounds(t *testing.T) {
+	c := &Client{opts: Options{BackoffBase: 100 * time.Millisecond, BackoffMax: 2 * time.Second}}
+
+	tests := []struct {
+		attempt int
+		ceil    time.Duration
+	}{
+		{attempt: 0, ceil: 100 * time.Millisecond},
+		{attempt: 1, ceil: 200 * time.Millisecond},
+		{attempt: 3, ceil: 800 * time.Millisecond},
+		{attempt: 5, ceil: 2 * time.Second},
+		{attempt: 10, ceil: 2 * time.Second},
+		// the shift overflows, the delay stays capped
+		{attempt: 70, ceil: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		for range 1000 {
+			got := c.backoff(tt.attempt)
+			if got < tt.ceil/2 || got >= tt.ceil {
+				t.Fatalf("backoff(%d) = %s, want in [%s, %s)", tt.attempt, got, tt.ceil/2, tt.ceil)
+			}
+		}
+	}
+}
+
+func TestBackoffTiny(t *testing.T) {
+	c := &Client{opts: Options{BackoffBase: 1, BackoffMax: 1}}
+
+	if got := c.backoff(0); got != 1 {
+		t.Fatalf("backoff(0) = %s, want 1ns", got)
+	}
+}
+
+func testClient(t *testing.T, h http.HandlerFunc, opts Options) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return New(host, port, opts)
+}
+
+func TestFetchInfoBreakerOutcome(t *testing.T) {
+	hang := func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}
+	unavailable := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	badRequest := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		ctx      func() (context.Context, context.CancelFunc)
+		failures int
+	}{
+		{
+			// the caller's deadline is shorter than all attempts together
+			name:    "hanging upstream outlives the caller's deadline",
+			handler: hang,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 150*time.Millisecond)
+			},
+			failures: 1,
+		},
+		{
+			name:    "5xx",
+			handler: unavailable,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			failures: 1,
+		},
+		{
+			name:    "4xx",
+			handler: badRequest,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			failures: 0,
+		},
+		{
+			name:    "canceled by the caller",
+			handler: hang,
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(50*time.Millisecond, cancel)
+
+				return ctx, cancel
+			},
+			failures: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testClient(t, tt.handler, Options{
+				Timeout:          100 * time.Millisecond,
+				MaxRetries:       3,
+				BackoffBase:      time.Millisecond,
+				BackoffMax:       5 * time.Millisecond,
+				BreakerThreshold: 5,
+				BreakerCooldown:  time.Minute,
+			})
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			_, err := c.fetchInfo(ctx, "X123XX150")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if got := c.BreakerStats().Failures; got != tt.failures {
+				t.Fatalf("breaker failures = %d, want %d", got, tt.failures)
+			}
+		})
+	}
+}
+
+func TestFetchInfoCircuitOpen(t *testing.T) {
+	calls := 0
+
+	c := testClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}, Options{BreakerThreshold: 2, BreakerCooldown: time.Minute})
+
+	for range 2 {
+		_, _ = c.fetchInfo(context.Background(), "X123XX150")
+	}
+
+	_, err := c.fetchInfo(context.Background(), "X123XX150")
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen", err)
+	}
+	if calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls)
+	}
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"sync"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned by GetInfo without calling the info service while
+// the circuit breaker is open.
+var ErrCircuitOpen error = &errs.Error{
+	Kind:    errs.ErrUpstreamUnavailable,
+	Message: "info api circuit breaker is open",
+}
+
+const (
+	stateClosed   = "closed"
+	stateOpen     = "open"
+	stateHalfOpen = "half-open"
+)
+
+// BreakerStats is a snapshot of the circuit breaker state.
+type BreakerStats struct {
+	State    string     `json:"state"`
+	Failures int        `json:"consecutiveFailures"`
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+}
+
+// breaker opens after threshold consecutive failures, rejects calls for the
+// cooldown period and then lets a single probe call through. A successful probe
+// closes the breaker, a failed one opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	state    string
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		state:     stateClosed,
+	}
+}
+
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.setState(stateHalfOpen)
+		b.probing = true
+
+		return true
+	case stateHalfOpen:
+		if b.probing {
+			return false
+		}
+
+		b.probing = true
+
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+
+	if b.state != stateClosed {
+		b.setState(stateClosed)
+	}
+}
+
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.threshold <= 0 {
+		return
+	}
+
+	if b.state == stateHalfOpen || b.failures >= b.threshold {
+		b.openedAt = time.Now()
+		b.setState(stateOpen)
+	}
+}
+
+// release frees the half-open probe slot when a call finished without telling
+// anything about the upstream health, e.g. the caller canceled it.
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+}
+
+func (b *breaker) stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := BreakerStats{
+		State:    b.state,
+		Failures: b.failures,
+	}
+
+	if b.state != stateClosed {
+		openedAt := b.openedAt
+		s.OpenedAt = &openedAt
+	}
+
+	return s
+}
+
+// setState must be called with mu held.
+func (b *breaker) setState(state string) {
+	log.Infof("info api circuit breaker: %s -> %s", b.state, state)
+	b.state = state
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"testing"
+	"time"
+)
+
+// expire makes the cooldown of an open breaker run out.
+func expire(b *breaker) {
+	b.mu.Lock()
+	b.openedAt = time.Now().Add(-2 * b.cooldown)
+	b.mu.Unlock()
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b := newBreaker(3, time.Minute)
+
+	for i := range 2 {
+		if !b.allow() {
+			t.Fatalf("call %d rejected while closed", i)
+		}
+		b.failure()
+	}
+
+	if got := b.stats().State; got != stateClosed {
+		t.Fatalf("state after 2 failures = %s, want %s", got, stateClosed)
+	}
+
+	b.failure()
+
+	if got := b.stats().State; got != stateOpen {
+		t.Fatalf("state after 3 failures = %s, want %s", got, stateOpen)
+	}
+	if b.allow() {
+		t.Fatal("open breaker allowed a call within the cooldown")
+	}
+}
+
+func TestBreakerSuccessResetsFailures(t *testing.T) {
+	b := newBreaker(2, time.Minute)
+
+	b.failure()
+	b.success()
+	b.failure()
+
+	if got := b.stats(); got.State != stateClosed || got.Failures != 1 {
+		t.Fatalf("stats = %+v, want closed with 1 failure", got)
+	}
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		finish func(b *breaker)
+		want   string
+	}{
+		{name: "probe succeeds", finish: (*breaker).success, want: stateClosed},
+		{name: "probe fails", finish: (*breaker).failure, want: stateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBreaker(1, time.Minute)
+
+			b.failure()
+			expire(b)
+
+			if !b.allow() {
+				t.Fatal("probe rejected after the cooldown")
+			}
+			if got := b.stats().State; got != stateHalfOpen {
+				t.Fatalf("state = %s, want %s", got, stateHalfOpen)
+			}
+			if b.allow() {
+				t.Fatal("second call allowed while probing")
+			}
+
+			tt.finish(b)
+
+			if got := b.stats().State; got != tt.want {
+				t.Fatalf("state = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBreakerReleaseFreesProbe(t *testing.T) {
+	b := newBreaker(1, time.Minute)
+
+	b.failure()
+	expire(b)
+
+	if !b.allow() {
+		t.Fatal("probe rejected after the cooldown")
+	}
+
+	b.release()
+
+	if got := b.stats().State; got != stateHalfOpen {
+		t.Fatalf("state = %s, want %s", got, stateHalfOpen)
+	}
+	if !b.allow() {
+		t.Fatal("released probe slot wasn't reused")
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	b := newBreaker(0, time.Minute)
+
+	for range 10 {
+		b.failure()
+	}
+
+	if !b.allow() {
+		t.Fatal("disabled breaker rejected a call")
+	}
+	if got := b.stats().State; got != stateClosed {
+		t.Fatalf("state = %s, want %s", got, stateClosed)
+	}
+}
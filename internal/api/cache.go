@@ -0,0 +1,114 @@
+package api
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// CacheEntry is a cached info API answer. NotFound entries cache 400
+// responses for plates the info service doesn't know.
+type CacheEntry struct {
+	Car       Car
+	NotFound  bool
+	ExpiresAt time.Time
+}
+
+// Cache stores info API answers by regNum. Implementations must not return
+// expired entries.
+type Cache interface {
+	Get(ctx context.Context, regNum string) (CacheEntry, bool, error)
+	Set(ctx context.Context, regNum string, e CacheEntry) error
+	Delete(ctx context.Context, regNum string) error
+}
+
+type bypassCacheKey struct{}
+
+// BypassCache returns a context which makes GetInfo skip the cache lookup and
+// call the info service. The fresh answer still replaces the cached one.
+func BypassCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassCacheKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassCacheKey{}).(bool)
+	return bypass
+}
+
+// LRUCache is an in-memory Cache holding at most size entries.
+type LRUCache struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+}
+
+type lruItem struct {
+	regNum string
+	entry  CacheEntry
+}
+
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:  size,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+func (c *LRUCache) Get(_ context.Context, regNum string) (CacheEntry, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[regNum]
+	if !ok {
+		return CacheEntry{}, false, nil
+	}
+
+	item := el.Value.(*lruItem)
+	if time.Now().After(item.entry.ExpiresAt) {
+		c.order.Remove(el)
+		delete(c.items, regNum)
+
+		return CacheEntry{}, false, nil
+	}
+
+	c.order.MoveToFront(el)
+
+	return item.entry, true, nil
+}
+
+func (c *LRUCache) Set(_ context.Context, regNum string, e CacheEntry) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[regNum]; ok {
+		el.Value.(*lruItem).entry = e
+		c.order.MoveToFront(el)
+
+		return nil
+	}
+
+	c.items[regNum] = c.order.PushFront(&lruItem{regNum: regNum, entry: e})
+
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruItem).regNum)
+	}
+
+	return nil
+}
+
+func (c *LRUCache) Delete(_ context.Context, regNum string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[regNum]; ok {
+		c.order.Remove(el)
+		delete(c.items, regNum)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"errors"
+
 	"github.com/ilyakaznacheev/cleanenv"
 	log "github.com/sirupsen/logrus"
 )
@@ -10,11 +12,45 @@ type Config struct {
 }
 
 type EnvSetting struct {
-	PGDSN    string `env:"PG_DSN"`
-	DBCancel int    `env:"DB_CANCEL"`
-	Port     string `env:"PORT"`
-	APIHost  string	`env:"API_HOST"`
-	APIPort  string `env:"API_PORT"` 
+	PGDSN       string `env:"PG_DSN"`
+	DBCancel    int    `env:"DB_CANCEL"`
+	Port        string `env:"PORT"`
+	APIHost     string `env:"API_HOST"`
+	APIPort     string `env:"API_PORT"`
+	AutoMigrate bool   `env:"AUTO_MIGRATE" env-default:"true"`
+
+	// bulk car creation: number of concurrent info API calls,
+	// per call and per request timeouts in seconds
+	EnrichWorkers      int `env:"ENRICH_WORKERS" env-default:"8"`
+	EnrichCallTimeout  int `env:"ENRICH_CALL_TIMEOUT" env-default:"5"`
+	EnrichTotalTimeout int `env:"ENRICH_TOTAL_TIMEOUT" env-default:"60"`
+
+	// info API client: request timeout in seconds, retries with backoff in
+	// milliseconds and circuit breaker, cooldown in seconds
+	APITimeout          int `env:"API_TIMEOUT" env-default:"3"`
+	APIMaxRetries       int `env:"API_MAX_RETRIES" env-default:"3"`
+	APIBackoffBase      int `env:"API_BACKOFF_BASE_MS" env-default:"100"`
+	APIBackoffMax       int `env:"API_BACKOFF_MAX_MS" env-default:"2000"`
+	APIBreakerThreshold int `env:"API_BREAKER_THRESHOLD" env-default:"5"`
+	APIBreakerCooldown  int `env:"API_BREAKER_COOLDOWN" env-default:"30"`
+
+	// info API cache: memory, postgres or none, TTLs in seconds
+	InfoCache            string `env:"INFO_CACHE" env-default:"memory"`
+	InfoCacheSize        int    `env:"INFO_CACHE_SIZE" env-default:"10000"`
+	InfoCacheTTL         int    `env:"INFO_CACHE_TTL" env-default:"86400"`
+	InfoCacheNegativeTTL int    `env:"INFO_CACHE_NEGATIVE_TTL" env-default:"3600"`
+
+	// expired entries of the postgres cache are removed every interval in seconds
+	InfoCacheCleanupInterval int `env:"INFO_CACHE_CLEANUP_INTERVAL" env-default:"3600"`
+
+	// soft deleted cars are purged after the retention in days, 0 keeps them
+	// forever, the purge runs every interval in seconds
+	PurgeRetention int `env:"PURGE_RETENTION_DAYS" env-default:"30"`
+	PurgeInterval  int `env:"PURGE_INTERVAL" env-default:"3600"`
+
+	// PUT of a regNum which isn't in the catalogue creates the car instead of
+	// answering 404, for clients mirroring another registry
+	PutCreate bool `env:"PUT_CREATE" env-default:"false"`
 }
 
 func New() *Config {
@@ -25,7 +61,30 @@ func New() *Config {
 		log.Panicf("read env config failed: %s", err)
 	}
 
+	err = e.validate()
+	if err != nil {
+		log.Panicf("invalid env config: %s", err)
+	}
+
 	return &Config{
 		Env: e,
 	}
 }
+
+// validate rejects settings which would make every request fail instead of
+// reporting them at startup.
+func (e *EnvSetting) validate() error {
+	if e.EnrichCallTimeout <= 0 {
+		return errors.New("ENRICH_CALL_TIMEOUT must be positive")
+	}
+
+	if e.EnrichTotalTimeout <= 0 {
+		return errors.New("ENRICH_TOTAL_TIMEOUT must be positive")
+	}
+
+	if e.InfoCache == "memory" && e.InfoCacheSize <= 0 {
+		return errors.New("INFO_CACHE_SIZE must be positive, set INFO_CACHE=none to disable the cache")
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/jackc/pgx/v5"
+)
+
+// Audited entities.
+const (
+	EntityCar   = "car"
+	EntityOwner = "owner"
+)
+
+// Audited actions.
+const (
+	ActionCreate   = "create"
+	ActionUpdate   = "update"
+	ActionDelete   = "delete"
+	ActionRestore  = "restore"
+	ActionTransfer = "transfer"
+	ActionMerge    = "merge"
+	ActionPurge    = "purge"
+)
+
+// systemActor is recorded for changes made outside of a request, like the
+// retention purge.
+const systemActor = "system"
+
+// AuditEvent is a change of a car or an owner. Before and After only hold
+// the fields which changed, Before is empty for creations and After for
+// deletions of owners.
+type AuditEvent struct {
+	ID         int             `json:"id"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Entity     string          `json:"entity"`
+	EntityID   int             `json:"entityId"`
+	Action     string          `json:"action"`
+	Actor      string          `json:"actor"`
+	RequestID  string          `json:"requestId,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+}
+
+type auditKey struct{}
+
+type auditInfo struct {
+	actor     string
+	requestID string
+}
+
+// WithAuditActor returns a context whose changes are recorded as made by actor
+// in the request with the given id.
+func WithAuditActor(ctx context.Context, actor, requestID string) context.Context {
+	return context.WithValue(ctx, auditKey{}, auditInfo{actor: actor, requestID: requestID})
+}
+
+// writeAudit records a change in the transaction which makes it. before and
+// after are compared field by field by their json form, nil stands for a
+// missing row.
+func writeAudit(ctx context.Context, tx pgx.Tx, entity string, id int, action string, before, after any) error {
+	info, ok := ctx.Value(auditKey{}).(auditInfo)
+	if !ok {
+		info.actor = systemActor
+	}
+
+	b, a, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+
+	stmt := `
+	INSERT INTO audit_events (entity, entity_id, action, actor, request_id, before, after)
+	VALUES ($1, $2, $3, $4, $5, $6, $7);`
+
+	_, err = tx.Exec(ctx, stmt, entity, id, action, info.actor, info.requestID, b, a)
+
+	return err
+}
+
+// auditDiff returns the json objects of before and after reduced to the
+// fields which differ.
+func auditDiff(before, after any) ([]byte, []byte, error) {
+	b, err := jsonObject(before)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := jsonObject(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if b != nil && a != nil {
+		for k, v := range b {
+			if reflect.DeepEqual(v, a[k]) {
+				delete(b, k)
+				delete(a, k)
+			}
+		}
+	}
+
+	bj, err := marshalObject(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aj, err := marshalObject(a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bj, aj, nil
+}
+
+func jsonObject(v any) (map[string]any, error) {
+	if v == nil || reflect.ValueOf(v).IsZero() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var res map[string]any
+
+	err = json.Unmarshal(data, &res)
+
+	return res, err
+}
+
+func marshalObject(m map[string]any) ([]byte, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(m)
+}
+
+// GetAuditEvents returns the matching events, newest first.
+func (db *Postgres) GetAuditEvents(ctx context.Context, q *types.GetAuditQuery) ([]*AuditEvent, error) {
+	var sb strings.Builder
+
+	sb.WriteString(`SELECT id, occurred_at, entity, entity_id, action, actor, request_id, before, after FROM audit_events `)
+
+	where, args := auditFilter(q)
+	sb.WriteString(where)
+	sb.WriteString("ORDER BY id DESC ")
+
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		sb.WriteString(fmt.Sprintf("LIMIT $%d ", len(args)))
+	}
+	if q.Offset > 0 {
+		args = append(args, q.Offset)
+		sb.WriteString(fmt.Sprintf("OFFSET $%d", len(args)))
+	}
+
+	rows, err := db.db.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*AuditEvent, 0)
+
+	for rows.Next() {
+		e := new(AuditEvent)
+
+		err = rows.Scan(&e.ID, &e.OccurredAt, &e.Entity, &e.EntityID, &e.Action, &e.Actor, &e.RequestID, &e.Before, &e.After)
+		if err != nil {
+			return nil, fmt.Errorf("database: %w", err)
+		}
+
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	return events, nil
+}
+
+func (db *Postgres) CountAuditEvents(ctx context.Context, q *types.GetAuditQuery) (int, error) {
+	where, args := auditFilter(q)
+
+	var total int
+
+	err := db.db.QueryRow(ctx, "SELECT count(*) FROM audit_events "+where, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("database: %w", err)
+	}
+
+	return total, nil
+}
+
+func auditFilter(q *types.GetAuditQuery) (string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
+
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if q.Entity != "" {
+		add("entity = $%d", q.Entity)
+	}
+	if q.EntityID != 0 {
+		add("entity_id = $%d", q.EntityID)
+	}
+	if len(q.Action) != 0 {
+		add("action = ANY($%d)", q.Action)
+	}
+	if q.Actor != "" {
+		add("actor = $%d", q.Actor)
+	}
+	if q.RequestID != "" {
+		add("request_id = $%d", q.RequestID)
+	}
+	if !q.From.IsZero() {
+		add("occurred_at >= $%d", q.From)
+	}
+	if !q.To.IsZero() {
+		add("occurred_at < $%d", q.To)
+	}
+
+	return whereClause(conds), args
+}
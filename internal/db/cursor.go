@@ -0,0 +1,152 @@
+package db
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+)
+
+var errBadCursor = errs.Validation("cursor is invalid or was issued for another sort order")
+
+// cursor is the sort key and car_id of the last row of a page. Sort is kept
+// to reject cursors reused with a different sort parameter.
+type cursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+	ID     int    `json:"id"`
+}
+
+// NextCursor returns the opaque cursor pointing after the car, the last one
+// of a page listed with the sort order of q.
+func NextCursor(q *types.GetCarQuery, last *Car) string {
+	cur := cursor{
+		Sort: sortKey(q.Sort),
+		ID:   last.ID,
+	}
+
+	for _, f := range q.Sort {
+		cur.Values = append(cur.Values, sortValue(f.Field, last))
+	}
+
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return ""
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string, sort []types.SortField) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return cursor{}, errBadCursor
+	}
+
+	var cur cursor
+
+	err = json.Unmarshal(b, &cur)
+	if err != nil || cur.Sort != sortKey(sort) || len(cur.Values) != len(sort) {
+		return cursor{}, errBadCursor
+	}
+
+	// json gives float64 and string, check and convert them back to the
+	// column types so a tampered cursor never reaches the query
+	for i, f := range sort {
+		if f.Field == "year" {
+			v, ok := cur.Values[i].(float64)
+			if !ok || v != math.Trunc(v) {
+				return cursor{}, errBadCursor
+			}
+
+			cur.Values[i] = int(v)
+
+			continue
+		}
+
+		v, ok := cur.Values[i].(string)
+		if !ok {
+			return cursor{}, errBadCursor
+		}
+
+		if f.Field == "created_at" || f.Field == "updated_at" {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return cursor{}, errBadCursor
+			}
+
+			cur.Values[i] = t
+		}
+	}
+
+	return cur, nil
+}
+
+// keysetCondition selects the rows which come after the cursor in the
+// carOrder order: (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ... OR (all keys
+// equal AND car_id > id), with < for descending keys.
+func keysetCondition(sort []types.SortField, cur cursor, args []any) (string, []any) {
+	var (
+		ors    []string
+		equals []string
+	)
+
+	for i, f := range sort {
+		col := sortColumns[f.Field]
+
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+
+		args = append(args, cur.Values[i])
+
+		ors = append(ors, "("+strings.Join(append(equals, fmt.Sprintf("%s %s $%d", col, op, len(args))), " AND ")+")")
+		equals = append(equals, fmt.Sprintf("%s = $%d", col, len(args)))
+	}
+
+	args = append(args, cur.ID)
+	ors = append(ors, "("+strings.Join(append(equals, fmt.Sprintf("c.car_id > $%d", len(args))), " AND ")+")")
+
+	return "(" + strings.Join(ors, " OR ") + ")", args
+}
+
+func sortKey(sort []types.SortField) string {
+	keys := make([]string, 0, len(sort))
+
+	for _, f := range sort {
+		if f.Desc {
+			keys = append(keys, "-"+f.Field)
+		} else {
+			keys = append(keys, f.Field)
+		}
+	}
+
+	return strings.Join(keys, ",")
+}
+
+func sortValue(field string, c *Car) any {
+	switch field {
+	case "reg_num":
+		return c.RegNum
+	case "mark":
+		return c.Mark
+	case "model":
+		return c.Model
+	case "year":
+		return c.Year
+	case "surname":
+		return c.Owner.Surname
+	case "created_at":
+		return c.CreatedAt.Format(time.RFC3339Nano)
+	case "updated_at":
+		return c.UpdatedAt.Format(time.RFC3339Nano)
+	default:
+		return nil
+	}
+}
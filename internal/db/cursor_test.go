@@ -0,0 +1,156 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/types"
+)
+
+func encodeCursor(json string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(json))
+}
+
+func TestDecodeCursor(t *testing.T) {
+	byYear := []types.SortField{{Field: "year", Desc: true}, {Field: "mark"}}
+	byCreated := []types.SortField{{Field: "created_at"}}
+
+	created := time.Date(2024, 4, 25, 20, 24, 17, 123, time.UTC)
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   []types.SortField
+		want   cursor
+		bad    bool
+	}{
+		{
+			name:   "year and mark",
+			cursor: encodeCursor(`{"s":"-year,mark","v":[2020,"lada"],"id":5}`),
+			sort:   byYear,
+			want:   cursor{Sort: "-year,mark", Values: []any{2020, "lada"}, ID: 5},
+		},
+		{
+			name:   "time",
+			cursor: encodeCursor(`{"s":"created_at","v":["` + created.Format(time.RFC3339Nano) + `"],"id":5}`),
+			sort:   byCreated,
+			want:   cursor{Sort: "created_at", Values: []any{created}, ID: 5},
+		},
+		{
+			name:   "string year",
+			cursor: encodeCursor(`{"s":"-year,mark","v":["2020","lada"],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "fractional year",
+			cursor: encodeCursor(`{"s":"-year,mark","v":[2020.5,"lada"],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "number mark",
+			cursor: encodeCursor(`{"s":"-year,mark","v":[2020,7],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "null mark",
+			cursor: encodeCursor(`{"s":"-year,mark","v":[2020,null],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "bad time",
+			cursor: encodeCursor(`{"s":"created_at","v":["yesterday"],"id":5}`),
+			sort:   byCreated,
+			bad:    true,
+		},
+		{
+			name:   "another sort order",
+			cursor: encodeCursor(`{"s":"year,mark","v":[2020,"lada"],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "missing values",
+			cursor: encodeCursor(`{"s":"-year,mark","v":[2020],"id":5}`),
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "not base64",
+			cursor: "!!!",
+			sort:   byYear,
+			bad:    true,
+		},
+		{
+			name:   "not json",
+			cursor: encodeCursor(`cursor`),
+			sort:   byYear,
+			bad:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(tt.cursor, tt.sort)
+			if tt.bad {
+				if !errors.Is(err, errBadCursor) {
+					t.Fatalf("err = %v, want errBadCursor", err)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("cursor = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	sort := []types.SortField{{Field: "year", Desc: true}, {Field: "mark"}}
+	cur := cursor{Values: []any{2020, "lada"}, ID: 5}
+
+	cond, args := keysetCondition(sort, cur, []any{"filter"})
+
+	wantCond := "((coalesce(c.year, 0) < $2) OR (coalesce(c.year, 0) = $2 AND c.mark > $3) OR " +
+		"(coalesce(c.year, 0) = $2 AND c.mark = $3 AND c.car_id > $4))"
+	if cond != wantCond {
+		t.Fatalf("condition = %s, want %s", cond, wantCond)
+	}
+
+	wantArgs := []any{"filter", 2020, "lada", 5}
+	if !reflect.DeepEqual(args, wantArgs) {
+		t.Fatalf("args = %v, want %v", args, wantArgs)
+	}
+}
+
+func TestNextCursorRoundTrip(t *testing.T) {
+	updated := time.Date(2026, 10, 18, 5, 8, 20, 123456789, time.UTC)
+
+	q := &types.GetCarQuery{Sort: types.Sort{{Field: "year"}, {Field: "surname", Desc: true}, {Field: "updated_at"}}}
+	last := &Car{ID: 42, Year: 2015, Owner: People{Surname: "иванов"}, UpdatedAt: updated}
+
+	got, err := decodeCursor(NextCursor(q, last), q.Sort)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := cursor{Sort: "year,-surname,updated_at", Values: []any{2015, "иванов", updated}, ID: 42}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("cursor = %#v, want %#v", got, want)
+	}
+
+	if _, err = decodeCursor(NextCursor(q, last), q.Sort[:2]); !errors.Is(err, errBadCursor) {
+		t.Fatalf("cursor of another sort order: err = %v, want errBadCursor", err)
+	}
+}
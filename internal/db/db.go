@@ -7,8 +7,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/regnum"
 	"github.com/basedalex/effective-mobile-test/internal/types"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -36,21 +39,28 @@ func NewPostgres(ctx context.Context, dbConnect string) (*Postgres, error) {
 }
 
 type Car struct {
-	ID         int       `json:"id" db:"id"`
-	RegNum     string    `json:"regNum" db:"regNum"`
+	ID     int    `json:"id" db:"id"`
+	RegNum string `json:"regNum" db:"regNum"`
+	// Region is the region code of the plate, empty for unrecognized plates
+	Region     string    `json:"region,omitempty" db:"region"`
+	RegionName string    `json:"regionName,omitempty"`
 	Mark       string    `json:"mark" db:"mark"`
 	Model      string    `json:"model" db:"model"`
 	Year       int       `json:"year" db:"year"`
 	Owner      People    `json:"owner"`
 	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
 	UpdatedAt  time.Time `json:"updatedAat" db:"created_at"`
+	// DeletedAt is set for soft deleted cars
+	DeletedAt *time.Time `json:"deletedAt,omitempty" db:"deleted_at"`
+	// Version is bumped by every change, UpdateCar checks it when it is set
+	Version int `json:"version" db:"version"`
 }
 
 type People struct {
-	ID		   int       `json:"id,omitempty" db:"id"`
-	Name       string    `json:"name"`
-	Surname    string    `json:"surname"`
-	Patronymic string    `json:"patronymic"`
+	ID         int    `json:"id,omitempty" db:"id"`
+	Name       string `json:"name"`
+	Surname    string `json:"surname"`
+	Patronymic string `json:"patronymic"`
 }
 
 type Cars struct {
@@ -66,247 +76,676 @@ type Cars struct {
 	UpdatedAt  time.Time `json:"updatedAat" db:"created_at"`
 }
 
-func (db *Postgres) CreateCar(ctx context.Context, c Car) error {
-	var ownerID int = -1
-	// check if owner already exists in db
-	query := `
-	SELECT id FROM people
-	WHERE name = $1 AND surname = $2 AND patronymic = $3;`
+// ConflictPolicy tells CreateCar what to do when a car with the same
+// registration number already exists.
+type ConflictPolicy string
+
+const (
+	// ConflictError keeps the existing car and returns a conflict error.
+	ConflictError ConflictPolicy = "error"
+	// ConflictSkip keeps the existing car and returns its id.
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing car with the new data.
+	ConflictOverwrite ConflictPolicy = "overwrite"
+)
 
-	row := db.db.QueryRow(ctx, query, c.Owner.Name, c.Owner.Surname, c.Owner.Patronymic)
+var (
+	errCarNotFound        = errs.NotFound("car not found")
+	errDeletedCarNotFound = errs.NotFound("deleted car not found")
+	errRegNumExists       = errs.Conflict("car with this regNum already exists")
+	errVersionMismatch    = errs.PreconditionFailed("car was changed by someone else, reload it and try again")
+)
+
+// errConflict rolls back the CreateCar transaction when the car already exists.
+var errConflict = errors.New("conflict")
+
+// CreateCar stores the car and its owner in one transaction and returns the
+// car id. inserted is false when a car with the same registration number
+// existed and was skipped or overwritten according to onConflict.
+func (db *Postgres) CreateCar(ctx context.Context, c Car, onConflict ConflictPolicy) (id int, inserted bool, err error) {
+	err = pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		ownerID, err := upsertOwner(ctx, tx, c.Owner)
+		if err != nil {
+			return err
+		}
 
-	err := row.Scan(&ownerID)
-	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
-		return fmt.Errorf("database: %w", err)
-	}
-	// add owner to people table if they don't exist just yet
-	if errors.Is(err, pgx.ErrNoRows) {
 		stmt := `
-		INSERT INTO people (name, surname, patronymic)
-		VALUES ($1, $2, $3)
-		RETURNING id;`
+		INSERT INTO cars (reg_num, mark, model, year, owner_id, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7)
+		ON CONFLICT (reg_num_key) WHERE deleted_at IS NULL DO NOTHING
+		RETURNING car_id, true;`
+
+		// the overwritten car is kept for the audit log
+		var before Car
+
+		if onConflict == ConflictOverwrite {
+			// xmax is zero only for freshly inserted rows
+			stmt = `
+			INSERT INTO cars (reg_num, mark, model, year, owner_id, created_at, updated_at)
+			VALUES ($1, $2, $3, NULLIF($4, 0), $5, $6, $7)
+			ON CONFLICT (reg_num_key) WHERE deleted_at IS NULL DO UPDATE
+			SET reg_num = EXCLUDED.reg_num, mark = EXCLUDED.mark, model = EXCLUDED.model,
+				year = EXCLUDED.year, owner_id = EXCLUDED.owner_id, updated_at = EXCLUDED.updated_at,
+				version = cars.version + 1
+			RETURNING car_id, xmax = 0;`
+
+			existingID, err := carIDByRegNum(ctx, tx, c.RegNum)
+			if err == nil {
+				before, err = getCarByID(ctx, tx, existingID)
+			}
+			if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+				return err
+			}
+		}
+
+		err = tx.QueryRow(ctx, stmt, c.RegNum, c.Mark, c.Model, c.Year, ownerID, c.CreatedAt, c.UpdatedAt).Scan(&id, &inserted)
+		if errors.Is(err, pgx.ErrNoRows) {
+			// DO NOTHING returns no rows on conflict, look up the car which is
+			// already there and roll back the owner we might have added
+			id, err = carIDByRegNum(ctx, tx, c.RegNum)
+			if err != nil {
+				return err
+			}
+
+			return errConflict
+		}
+		if err != nil {
+			return err
+		}
 
-		row := db.db.QueryRow(ctx, stmt, c.Owner.Name, c.Owner.Surname, c.Owner.Patronymic)
+		// new cars start their history, overwritten ones get a new owner
+		// period if the fresh enrichment reports a different owner
+		err = recordOwnership(ctx, tx, id, ownerID, c.UpdatedAt)
+		if err != nil {
+			return err
+		}
 
-		err := row.Scan(&ownerID)
+		after, err := getCarByID(ctx, tx, id)
 		if err != nil {
-			return fmt.Errorf("database: %w", err)
+			return err
 		}
+
+		action := ActionUpdate
+		if inserted {
+			action = ActionCreate
+			before = Car{}
+		}
+
+		return writeAudit(ctx, tx, EntityCar, id, action, before, after)
+	})
+
+	switch {
+	case err == nil:
+		return id, inserted, nil
+	case !errors.Is(err, errConflict):
+		return 0, false, fmt.Errorf("database: %w", err)
+	case onConflict == ConflictSkip:
+		return id, false, nil
+	default:
+		return id, false, errRegNumExists
 	}
+}
+
+// carIDByRegNum returns the id of the live car with the plate or
+// pgx.ErrNoRows.
+func carIDByRegNum(ctx context.Context, q querier, regNum string) (int, error) {
+	query := `SELECT car_id FROM cars WHERE reg_num_key = upper(regexp_replace($1, '\s', '', 'g')) AND deleted_at IS NULL;`
+
+	var id int
+
+	err := q.QueryRow(ctx, query, regNum).Scan(&id)
 
+	return id, err
+}
+
+// upsertOwner returns the id of the person with the same name, surname and
+// patronymic, adding them to people if they don't exist just yet. The no-op
+// update makes RETURNING work for existing rows and waits for concurrent
+// inserts of the same owner instead of failing.
+func upsertOwner(ctx context.Context, tx pgx.Tx, p People) (int, error) {
 	stmt := `
-	INSERT INTO cars (reg_num, mark, model, year, owner_id, created_at, updated_at)
-	VALUES ($1, $2, $3, $4, $5, $6, $7);`
+	INSERT INTO people (name, surname, patronymic)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (name, surname, patronymic) DO UPDATE
+	SET name = EXCLUDED.name
+	RETURNING id;`
+
+	var id int
 
-	_, err = db.db.Exec(ctx, stmt, c.RegNum, c.Mark, c.Model, c.Year, ownerID, c.CreatedAt, c.UpdatedAt)
+	err := tx.QueryRow(ctx, stmt, p.Name, p.Surname, p.Patronymic).Scan(&id)
 	if err != nil {
-		return fmt.Errorf("database: %w", err)
+		return 0, err
 	}
 
-	return nil
+	return id, nil
 }
 
 func (db *Postgres) GetCar(ctx context.Context, c *types.GetCarQuery) ([]*Car, error) {
 	var sb strings.Builder
 
-	stmt := `SELECT 
-			c.car_id, 
-			c.reg_num, 
-			c.mark, 
-			c.model, 
-			c.year, 
-			p.name, 
-			p.surname, 
-			p.patronymic, 
-			c.created_at, 
-			c.updated_at
+	sb.WriteString(`SELECT
+			c.car_id,
+			c.reg_num,
+			coalesce(c.region, ''),
+			c.mark,
+			c.model,
+			coalesce(c.year, 0),
+			p.id,
+			p.name,
+			p.surname,
+			p.patronymic,
+			c.created_at,
+			c.updated_at,
+			c.deleted_at,
+			c.version
 			FROM cars c
-			JOIN people p on c.owner_id = p.id `
+			JOIN people p on c.owner_id = p.id `)
 
-	if c.RegNum != "" || c.Mark != "" || c.Model != "" || c.Year != 0 || c.Name != "" || c.Surname != "" || c.Patronymic != "" {
-		stmt += ` WHERE `
-	}
+	conds, args := carConditions(c)
 
-	sb.WriteString(stmt)
+	// keyset pagination continues after the cursor and ignores the offset
+	if c.Cursor != "" {
+		cur, err := decodeCursor(c.Cursor, c.Sort)
+		if err != nil {
+			return nil, err
+		}
 
-	var args []any
-	
-	if c.RegNum != "" {
-		args = append(args, c.RegNum)
-		sb.WriteString(fmt.Sprintf("reg_num = $%d AND ", len(args)))
-	}
-	if c.Mark != "" {
-		args = append(args, c.Mark)
-		sb.WriteString(fmt.Sprintf("mark = $%d AND ", len(args)))
-	}
-	if c.Model != "" {
-		args = append(args, c.Model)
-		sb.WriteString(fmt.Sprintf("model = $%d AND ", len(args)))
-	}
-	if c.Year != 0 {
-		args = append(args, c.Year)
-		sb.WriteString(fmt.Sprintf("year = $%d AND ", len(args)))
-	}
-	if c.Name != "" {
-		args = append(args, c.Name)
-		sb.WriteString(fmt.Sprintf("name = $%d AND ", len(args)))
-	}
-	if c.Surname != "" {
-		args = append(args, c.Surname)
-		sb.WriteString(fmt.Sprintf("surname = $%d AND ", len(args)))
-	}
-	if c.Patronymic != "" {
-		args = append(args, c.Patronymic)
-		sb.WriteString(fmt.Sprintf("patronymic = $%d AND ", len(args)))
+		var cond string
+
+		cond, args = keysetCondition(c.Sort, cur, args)
+		conds = append(conds, cond)
 	}
 
-	sqlQuery := strings.TrimSuffix(sb.String(), "AND ")
+	sb.WriteString(whereClause(conds))
+	sb.WriteString(carOrder(c.Sort))
 
-	switch {
-		case c.Limit != 0 && c.Offset == 0: sqlQuery+=fmt.Sprintf("LIMIT %d", c.Limit) 
-		case c.Limit == 0 && c.Offset != 0: sqlQuery+=fmt.Sprintf("OFFSET %d", c.Offset)
-		case c.Limit != 0 && c.Offset != 0: sqlQuery+=fmt.Sprintf("LIMIT %d OFFSET %d", c.Limit, c.Offset)
+	if c.Limit > 0 {
+		args = append(args, c.Limit)
+		sb.WriteString(fmt.Sprintf("LIMIT $%d ", len(args)))
+	}
+	if c.Offset > 0 && c.Cursor == "" {
+		args = append(args, c.Offset)
+		sb.WriteString(fmt.Sprintf("OFFSET $%d", len(args)))
 	}
 
-	sqlQuery = sqlQuery + ";"
+	sb.WriteString(";")
 
-	rows, err := db.db.Query(ctx, sqlQuery, args...)
+	rows, err := db.db.Query(ctx, sb.String(), args...)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 
 	cars := make([]*Car, 0)
 
 	for rows.Next() {
 		car := new(Car)
 
-		err = rows.Scan(&car.ID, &car.RegNum, &car.Mark, &car.Model, &car.Year, &car.Owner.Name, &car.Owner.Surname, &car.Owner.Patronymic, &car.CreatedAt, &car.UpdatedAt)
+		err = rows.Scan(&car.ID, &car.RegNum, &car.Region, &car.Mark, &car.Model, &car.Year, &car.Owner.ID, &car.Owner.Name, &car.Owner.Surname, &car.Owner.Patronymic, &car.CreatedAt, &car.UpdatedAt, &car.DeletedAt, &car.Version)
 		if err != nil {
 			return nil, err
 		}
 
+		car.RegionName = regnum.RegionName(car.Region)
+
 		cars = append(cars, car)
 	}
 
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
 	return cars, nil
 }
 
-func (db *Postgres) UpdateCar(ctx context.Context, c Car) (Car, error) {
-	var ownerID int = -1
-	var sb strings.Builder
+// CountCars returns the number of cars matching the filters of c, ignoring
+// its sort, limit and offset.
+func (db *Postgres) CountCars(ctx context.Context, c *types.GetCarQuery) (int, error) {
+	where, args := carFilter(c)
 
-	sb.WriteString("UPDATE cars SET ")
+	query := `SELECT count(*) FROM cars c JOIN people p on c.owner_id = p.id ` + where
 
-	var args []any
-	var ownerArgs []any
+	var total int
 
-	if c.RegNum != "" {
-		args = append(args, c.RegNum)
-		sb.WriteString(fmt.Sprintf("reg_num = $%d, ", len(args)))
-	}
-	if c.Mark != "" {
-		args = append(args, c.Mark)
-		sb.WriteString(fmt.Sprintf("mark = $%d, ", len(args)))
-	}
-	if c.Model != "" {
-		args = append(args, c.Model)
-		sb.WriteString(fmt.Sprintf("model = $%d, ", len(args)))
+	err := db.db.QueryRow(ctx, query, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("database: %w", err)
 	}
-	if c.Year != 0 {
-		args = append(args, c.Year)
-		sb.WriteString(fmt.Sprintf("year = $%d, ", len(args)))
+
+	return total, nil
+}
+
+// carFilter builds the WHERE clause of a car search over cars c joined with
+// people p. All values are passed as bound parameters.
+func carFilter(c *types.GetCarQuery) (string, []any) {
+	conds, args := carConditions(c)
+	return whereClause(conds), args
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
 	}
 
-	var ownerSB strings.Builder
+	return "WHERE " + strings.Join(conds, " AND ") + " "
+}
+
+func carConditions(c *types.GetCarQuery) ([]string, []any) {
+	var (
+		conds []string
+		args  []any
+	)
 
-	ownerSB.WriteString("UPDATE people SET ")
+	add := func(cond string, arg any) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
 
-	if c.Owner.Name != "" {
-		ownerArgs = append(ownerArgs, c.Owner.Name)
-		ownerSB.WriteString(fmt.Sprintf("name = $%d, ", len(ownerArgs)))
+	if len(c.RegNum) != 0 {
+		add("c.reg_num_key = ANY($%d)", c.RegNum)
+	}
+	if !c.IncludeDeleted {
+		conds = append(conds, "c.deleted_at IS NULL")
+	}
+	if len(c.Region) != 0 {
+		add("c.region = ANY($%d)", c.Region)
+	}
+	if len(c.Mark) != 0 {
+		add("c.mark = ANY($%d)", c.Mark)
+	}
+	if len(c.Model) != 0 {
+		add("c.model = ANY($%d)", c.Model)
+	}
+	if c.ModelPrefix != "" {
+		add("c.model ILIKE $%d", escapeLike(c.ModelPrefix)+"%")
+	}
+	if c.ModelContains != "" {
+		add("c.model ILIKE $%d", "%"+escapeLike(c.ModelContains)+"%")
+	}
+	if len(c.Year) != 0 {
+		add("c.year = ANY($%d)", c.Year)
+	}
+	if c.YearFrom != 0 {
+		add("c.year >= $%d", c.YearFrom)
 	}
-	if c.Owner.Surname != "" {
-		ownerArgs = append(ownerArgs, c.Owner.Surname)
-		ownerSB.WriteString(fmt.Sprintf("surname = $%d, ", len(ownerArgs)))
+	if c.YearTo != 0 {
+		add("c.year <= $%d", c.YearTo)
 	}
-	if c.Owner.Patronymic != "" {
-		ownerArgs = append(ownerArgs, c.Owner.Patronymic)
-		ownerSB.WriteString(fmt.Sprintf("patronymic = $%d, ", len(ownerArgs)))
+	if len(c.Name) != 0 {
+		add("p.name = ANY($%d)", c.Name)
+	}
+	if len(c.Surname) != 0 {
+		add("p.surname = ANY($%d)", c.Surname)
+	}
+	if c.SurnamePrefix != "" {
+		add("p.surname ILIKE $%d", escapeLike(c.SurnamePrefix)+"%")
+	}
+	if c.SurnameContains != "" {
+		add("p.surname ILIKE $%d", "%"+escapeLike(c.SurnameContains)+"%")
+	}
+	if len(c.Patronymic) != 0 {
+		add("p.patronymic = ANY($%d)", c.Patronymic)
+	}
+	if c.OwnerID != 0 {
+		add("c.owner_id = $%d", c.OwnerID)
+	}
+	if !c.CreatedAfter.IsZero() {
+		add("c.created_at > $%d", c.CreatedAfter)
+	}
+	if !c.CreatedBefore.IsZero() {
+		add("c.created_at < $%d", c.CreatedBefore)
+	}
+	if !c.UpdatedAfter.IsZero() {
+		add("c.updated_at > $%d", c.UpdatedAfter)
+	}
+	if !c.UpdatedBefore.IsZero() {
+		add("c.updated_at < $%d", c.UpdatedBefore)
 	}
 
-	if c.Owner.Name != "" || c.Owner.Surname != "" || c.Owner.Patronymic != ""  {
-		query := "SELECT owner_id FROM cars WHERE car_id = $1;"
-		row := db.db.QueryRow(ctx, query, c.ID)
-		err := row.Scan(&ownerID)
-		if err != nil {
-			return Car{}, fmt.Errorf("database: %w", err)
+	return conds, args
+}
+
+// sortColumns maps the sortable fields to their columns.
+var sortColumns = map[string]string{
+	"reg_num":    "c.reg_num",
+	"mark":       "c.mark",
+	"model":      "c.model",
+	"year":       "coalesce(c.year, 0)",
+	"surname":    "p.surname",
+	"created_at": "c.created_at",
+	"updated_at": "c.updated_at",
+}
+
+func carOrder(sort []types.SortField) string {
+	var sb strings.Builder
+
+	sb.WriteString("ORDER BY ")
+
+	for _, f := range sort {
+		col, ok := sortColumns[f.Field]
+		if !ok {
+			continue
+		}
+
+		sb.WriteString(col)
+
+		if f.Desc {
+			sb.WriteString(" DESC")
 		}
+
+		sb.WriteString(", ")
 	}
-	sqlQuery := strings.TrimSuffix(ownerSB.String(), ", ")
 
-	ownerArgs = append(ownerArgs, ownerID)
-	sqlQuery = sqlQuery + fmt.Sprintf(" WHERE id = $%d;", len(ownerArgs))
-	
-	if len(ownerArgs) > 1 {
-		_, err := db.db.Exec(ctx, sqlQuery, ownerArgs...)
+	sb.WriteString("c.car_id ")
+
+	return sb.String()
+}
+
+// escapeLike escapes the LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// querier is implemented by both the pool and transactions.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// CarPatch is a partial update of a car. Only the non-nil fields change, a
+// zero Year clears the year and an empty Patronymic clears the patronymic.
+type CarPatch struct {
+	ID int
+	// Version must match the stored version unless it is zero
+	Version    int
+	RegNum     *string
+	Mark       *string
+	Model      *string
+	Year       *int
+	Name       *string
+	Surname    *string
+	Patronymic *string
+	// OwnerTransfer records an owner change as a transfer to the new owner,
+	// audited as a transfer, instead of a correction of the current owner's data
+	OwnerTransfer bool
+}
+
+// UpdateCar applies the patch in one transaction, provided p.Version is zero
+// or matches the stored version. Owner fields never modify the shared people
+// row: the car is re-pointed to the person matching the resulting name,
+// surname and patronymic, who is created if needed, so other cars of the
+// previous owner are left untouched.
+func (db *Postgres) UpdateCar(ctx context.Context, p CarPatch) (Car, error) {
+	var car Car
+
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		var owner People
+
+		query := `
+		SELECT p.id, p.name, p.surname, p.patronymic
+		FROM cars c
+		JOIN people p on c.owner_id = p.id
+		WHERE c.car_id = $1 AND c.deleted_at IS NULL
+		FOR UPDATE OF c;`
+
+		err := tx.QueryRow(ctx, query, p.ID).Scan(&owner.ID, &owner.Name, &owner.Surname, &owner.Patronymic)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errCarNotFound
+		}
 		if err != nil {
-			return Car{}, fmt.Errorf("database: %w", err)
+			return err
 		}
-	}
 
-	now := time.Now()
-	args = append(args, now)
-	sb.WriteString(fmt.Sprintf("updated_at = $%d ", len(args)))
+		before, err := getCarByID(ctx, tx, p.ID)
+		if err != nil {
+			return err
+		}
+
+		if p.Version != 0 && p.Version != before.Version {
+			return errVersionMismatch
+		}
+
+		now := time.Now()
+
+		var (
+			sb   strings.Builder
+			args []any
+		)
+
+		sb.WriteString("UPDATE cars SET ")
 
-	args = append(args, c.ID)
-	sb.WriteString(fmt.Sprintf("WHERE car_id = $%d;", len(args)))
+		set := func(column string, value any) {
+			args = append(args, value)
+			sb.WriteString(fmt.Sprintf("%s = $%d, ", column, len(args)))
+		}
+
+		if p.RegNum != nil {
+			set("reg_num", *p.RegNum)
+		}
+		if p.Mark != nil {
+			set("mark", *p.Mark)
+		}
+		if p.Model != nil {
+			set("model", *p.Model)
+		}
+		if p.Year != nil {
+			var year *int
+			if *p.Year != 0 {
+				year = p.Year
+			}
 
-	if len(ownerArgs) != 0 || len(args) != 0 {
-		_, err := db.db.Query(ctx, sb.String(), args...)
+			set("year", year)
+		}
+
+		action := ActionUpdate
+		newOwner := owner
+
+		if p.Name != nil {
+			newOwner.Name = *p.Name
+		}
+		if p.Surname != nil {
+			newOwner.Surname = *p.Surname
+		}
+		if p.Patronymic != nil {
+			newOwner.Patronymic = *p.Patronymic
+		}
+
+		if newOwner != owner {
+			ownerID, err := upsertOwner(ctx, tx, newOwner)
+			if err != nil {
+				return err
+			}
+
+			set("owner_id", ownerID)
+
+			if p.OwnerTransfer {
+				action = ActionTransfer
+				err = recordOwnership(ctx, tx, p.ID, ownerID, now)
+			} else {
+				// this corrects the owner's data rather than transferring the car,
+				// so the current ownership period is kept and re-pointed
+				_, err = tx.Exec(ctx, `
+				UPDATE ownership_history SET owner_id = $1
+				WHERE car_id = $2 AND owned_to IS NULL;`, ownerID, p.ID)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		args = append(args, now)
+		sb.WriteString(fmt.Sprintf("updated_at = $%d, version = version + 1 ", len(args)))
+
+		args = append(args, p.ID)
+		sb.WriteString(fmt.Sprintf("WHERE car_id = $%d;", len(args)))
+
+		_, err = tx.Exec(ctx, sb.String(), args...)
+		if isUniqueViolation(err) {
+			return errRegNumExists
+		}
+		if err != nil {
+			return err
+		}
+
+		car, err = getCarByID(ctx, tx, p.ID)
 		if err != nil {
-			return Car{}, fmt.Errorf("database: %w", err)
+			return err
 		}
+
+		return writeAudit(ctx, tx, EntityCar, p.ID, action, before, car)
+	})
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return Car{}, err
+	}
+	if err != nil {
+		return Car{}, fmt.Errorf("database: %w", err)
 	}
 
-	query := `SELECT 
-	c.car_id, 
-	c.reg_num, 
-	c.mark, 
-	c.model, 
-	c.year, 
-	p.name, 
-	p.surname, 
-	p.patronymic, 
-	c.created_at, 
-	c.updated_at
-	FROM cars c
-	JOIN people p on c.owner_id = p.id 
-	WHERE car_id = $1`
+	return car, nil
+}
 
-	var car Car
+// uniqueViolation is the postgres SQLSTATE of unique_violation.
+const uniqueViolation = "23505"
 
-	row := db.db.QueryRow(ctx, query, c.ID)
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
 
-	err := row.Scan(
-		&car.ID, &car.RegNum, &car.Mark, 
-		&car.Model, &car.Year, &car.Owner.Name, 
-		&car.Owner.Surname, &car.Owner.Patronymic, 
-		&car.CreatedAt, &car.UpdatedAt)
+// GetCarByID returns the car with its owner, soft deleted cars included.
+func (db *Postgres) GetCarByID(ctx context.Context, id int) (Car, error) {
+	car, err := getCarByID(ctx, db.db, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return Car{}, err
+	}
+	if err != nil {
+		return Car{}, fmt.Errorf("database: %w", err)
+	}
+
+	return car, nil
+}
 
+// GetCarByRegNum returns the live car with the plate.
+func (db *Postgres) GetCarByRegNum(ctx context.Context, regNum string) (Car, error) {
+	id, err := carIDByRegNum(ctx, db.db, regNum)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Car{}, errCarNotFound
+	}
 	if err != nil {
 		return Car{}, fmt.Errorf("database: %w", err)
 	}
-	
+
+	return db.GetCarByID(ctx, id)
+}
+
+func getCarByID(ctx context.Context, q querier, id int) (Car, error) {
+	query := `SELECT
+	c.car_id,
+	c.reg_num,
+	coalesce(c.region, ''),
+	c.mark,
+	c.model,
+	coalesce(c.year, 0),
+	p.id,
+	p.name,
+	p.surname,
+	p.patronymic,
+	c.created_at,
+	c.updated_at,
+	c.deleted_at,
+	c.version
+	FROM cars c
+	JOIN people p on c.owner_id = p.id
+	WHERE c.car_id = $1`
+
+	var car Car
+
+	err := q.QueryRow(ctx, query, id).Scan(
+		&car.ID, &car.RegNum, &car.Region, &car.Mark,
+		&car.Model, &car.Year, &car.Owner.ID, &car.Owner.Name,
+		&car.Owner.Surname, &car.Owner.Patronymic,
+		&car.CreatedAt, &car.UpdatedAt, &car.DeletedAt, &car.Version)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Car{}, errCarNotFound
+	}
+	if err != nil {
+		return Car{}, err
+	}
+
+	car.RegionName = regnum.RegionName(car.Region)
+
 	return car, nil
 }
 
+// DeleteCar marks the car as deleted, PurgeDeletedCars removes it for good
+// once the retention period is over.
 func (db *Postgres) DeleteCar(ctx context.Context, id int) error {
-	stmtDeleteCar := `DELETE FROM cars WHERE car_id = $1`
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		return setDeleted(ctx, tx, id, true)
+	})
 
-	_, err := db.db.Exec(ctx, stmtDeleteCar, id)
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
 	if err != nil {
 		return fmt.Errorf("database: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// RestoreCar undoes DeleteCar. It fails with a conflict if another car took
+// the plate in the meantime.
+func (db *Postgres) RestoreCar(ctx context.Context, id int) (Car, error) {
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		return setDeleted(ctx, tx, id, false)
+	})
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return Car{}, err
+	}
+	if err != nil {
+		return Car{}, fmt.Errorf("database: %w", err)
+	}
+
+	return db.GetCarByID(ctx, id)
+}
+
+// setDeleted soft deletes or restores the car and records it.
+func setDeleted(ctx context.Context, tx pgx.Tx, id int, deleted bool) error {
+	stmt := `UPDATE cars SET deleted_at = now(), updated_at = now(), version = version + 1 WHERE car_id = $1 AND deleted_at IS NULL`
+	action, notFound := ActionDelete, errCarNotFound
+
+	if !deleted {
+		stmt = `UPDATE cars SET deleted_at = NULL, updated_at = now(), version = version + 1 WHERE car_id = $1 AND deleted_at IS NOT NULL`
+		action, notFound = ActionRestore, errDeletedCarNotFound
+	}
+
+	before, err := getCarByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	tag, err := tx.Exec(ctx, stmt, id)
+	if isUniqueViolation(err) {
+		return errRegNumExists
+	}
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return notFound
+	}
+
+	after, err := getCarByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	return writeAudit(ctx, tx, EntityCar, id, action, before, after)
+}
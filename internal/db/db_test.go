@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testDB connects to the throwaway database in TEST_PG_DSN and migrates it,
+// the test is skipped when the variable isn't set. The tests write to it, so
+// it must not be the database of the service.
+func testDB(t *testing.T) *Postgres {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_PG_DSN")
+	if dsn == "" {
+		t.Skip("TEST_PG_DSN is not set")
+	}
+
+	if dsn == os.Getenv("PG_DSN") {
+		t.Fatal("TEST_PG_DSN is the database from PG_DSN, point it at a throwaway one")
+	}
+
+	ctx := context.Background()
+
+	err := Migrate(ctx, dsn, "up")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := NewPostgres(ctx, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(db.db.Close)
+
+	return db
+}
+
+func TestCreateCarSameOwnerConcurrently(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	const workers = 50
+
+	// the surname and the region keep this run apart from existing data
+	run := time.Now().UnixNano()
+
+	owner := People{
+		Name:       "иван",
+		Surname:    fmt.Sprintf("конкурентный%d", run),
+		Patronymic: "иванович",
+	}
+
+	t.Cleanup(func() {
+		_, err := db.db.Exec(ctx, `
+		WITH owners AS (
+			SELECT id FROM people WHERE surname = $1
+		)
+		DELETE FROM audit_events
+		WHERE (entity = 'car' AND entity_id IN (SELECT car_id FROM cars WHERE owner_id IN (SELECT id FROM owners)))
+		OR (entity = 'owner' AND entity_id IN (SELECT id FROM owners));`, owner.Surname)
+		if err != nil {
+			t.Error(err)
+		}
+
+		_, err = db.db.Exec(ctx, `
+		DELETE FROM cars WHERE owner_id IN (SELECT id FROM people WHERE surname = $1);`, owner.Surname)
+		if err != nil {
+			t.Error(err)
+		}
+
+		_, err = db.db.Exec(ctx, `DELETE FROM people WHERE surname = $1;`, owner.Surname)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	var (
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+		results = make(chan error, workers)
+	)
+
+	for i := range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			<-start
+
+			car := Car{
+				RegNum:    fmt.Sprintf("Т%03dТТ%d", i+1, 700+run%100),
+				Mark:      "lada",
+				Model:     "vesta",
+				Year:      2020,
+				Owner:     owner,
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+
+			_, _, err := db.CreateCar(ctx, car, ConflictError)
+			results <- err
+		}()
+	}
+
+	close(start)
+	wg.Wait()
+	close(results)
+
+	for err := range results {
+		if err != nil {
+			t.Errorf("CreateCar: %s", err)
+		}
+	}
+
+	var owners int
+
+	err := db.db.QueryRow(ctx, `
+	SELECT count(*) FROM people
+	WHERE name = $1 AND surname = $2 AND patronymic = $3;`,
+		owner.Name, owner.Surname, owner.Patronymic).Scan(&owners)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if owners != 1 {
+		t.Fatalf("people rows for the owner = %d, want 1", owners)
+	}
+
+	var cars int
+
+	err = db.db.QueryRow(ctx, `
+	SELECT count(*) FROM cars c JOIN people p ON p.id = c.owner_id
+	WHERE p.surname = $1;`, owner.Surname).Scan(&cars)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cars != workers {
+		t.Fatalf("cars of the owner = %d, want %d", cars, workers)
+	}
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/api"
+	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// InfoCache is an api.Cache kept in the info_cache table, so cached info API
+// answers survive restarts.
+type InfoCache struct {
+	db *Postgres
+}
+
+func NewInfoCache(db *Postgres) *InfoCache {
+	return &InfoCache{db: db}
+}
+
+func (c *InfoCache) Get(ctx context.Context, regNum string) (api.CacheEntry, bool, error) {
+	query := `
+	SELECT car, not_found, expires_at FROM info_cache
+	WHERE reg_num = $1 AND expires_at > now();`
+
+	var (
+		e   api.CacheEntry
+		car *api.Car
+	)
+
+	err := c.db.db.QueryRow(ctx, query, regNum).Scan(&car, &e.NotFound, &e.ExpiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return api.CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return api.CacheEntry{}, false, fmt.Errorf("database: %w", err)
+	}
+
+	if car != nil {
+		e.Car = *car
+	}
+
+	return e, true, nil
+}
+
+func (c *InfoCache) Set(ctx context.Context, regNum string, e api.CacheEntry) error {
+	stmt := `
+	INSERT INTO info_cache (reg_num, car, not_found, expires_at)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (reg_num) DO UPDATE
+	SET car = EXCLUDED.car, not_found = EXCLUDED.not_found, expires_at = EXCLUDED.expires_at;`
+
+	var car *api.Car
+	if !e.NotFound {
+		car = &e.Car
+	}
+
+	_, err := c.db.db.Exec(ctx, stmt, regNum, car, e.NotFound, e.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+
+	return nil
+}
+
+func (c *InfoCache) Delete(ctx context.Context, regNum string) error {
+	_, err := c.db.db.Exec(ctx, `DELETE FROM info_cache WHERE reg_num = $1;`, regNum)
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the entries Get no longer returns and reports how many
+// there were.
+func (c *InfoCache) DeleteExpired(ctx context.Context) (int, error) {
+	tag, err := c.db.db.Exec(ctx, `DELETE FROM info_cache WHERE expires_at < now();`)
+	if err != nil {
+		return 0, fmt.Errorf("database: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// RunCleanup calls DeleteExpired every interval until ctx is done.
+func (c *InfoCache) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := c.DeleteExpired(ctx)
+		switch {
+		case err != nil:
+			log.Error(err)
+		case n > 0:
+			log.Infof("removed %d expired info cache entries", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
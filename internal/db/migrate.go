@@ -0,0 +1,95 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/basedalex/effective-mobile-test/internal/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+	log "github.com/sirupsen/logrus"
+)
+
+// Migrate runs the embedded migrations against the database. Supported commands
+// are up, down, status and redo. The run holds a postgres advisory lock so that
+// several replicas starting at the same time don't apply migrations concurrently.
+func Migrate(ctx context.Context, dbConnect, command string) error {
+	// parse with pgxpool so pool-only options like pool_max_conns are accepted
+	config, err := pgxpool.ParseConfig(dbConnect)
+	if err != nil {
+		return fmt.Errorf("error parsing connection string: %w", err)
+	}
+
+	sqlDB := stdlib.OpenDB(*config.ConnConfig)
+	defer sqlDB.Close()
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return fmt.Errorf("migrations: %w", err)
+	}
+
+	provider, err := goose.NewProvider(goose.DialectPostgres, sqlDB, migrations.FS, goose.WithSessionLocker(locker))
+	if err != nil {
+		return fmt.Errorf("migrations: %w", err)
+	}
+
+	switch command {
+	case "up":
+		results, err := provider.Up(ctx)
+		logResults(results...)
+		if err != nil {
+			return fmt.Errorf("migrations up: %w", err)
+		}
+	case "down":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("migrations down: %w", err)
+		}
+		logResults(result)
+	case "redo":
+		result, err := provider.Down(ctx)
+		if err != nil {
+			return fmt.Errorf("migrations redo: %w", err)
+		}
+		logResults(result)
+
+		result, err = provider.UpByOne(ctx)
+		if err != nil {
+			return fmt.Errorf("migrations redo: %w", err)
+		}
+		logResults(result)
+	case "status":
+		statuses, err := provider.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("migrations status: %w", err)
+		}
+
+		for _, s := range statuses {
+			if s.State == goose.StateApplied {
+				log.Infof("%s: applied at %s", s.Source.Path, s.AppliedAt.Format("2006-01-02 15:04:05"))
+			} else {
+				log.Infof("%s: %s", s.Source.Path, s.State)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %q, expected up, down, status or redo", command)
+	}
+
+	return nil
+}
+
+func logResults(results ...*goose.MigrationResult) {
+	if len(results) == 0 {
+		log.Info("migrations: nothing to apply")
+		return
+	}
+
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		log.Info("migrations: ", r)
+	}
+}
@@ -0,0 +1,342 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// foreignKeyViolation is the postgres SQLSTATE of foreign_key_violation.
+const foreignKeyViolation = "23503"
+
+var (
+	errOwnerNotFound = errs.NotFound("owner not found")
+	errOwnerExists   = errs.Conflict("owner with this name, surname and patronymic already exists, merge them instead")
+	errOwnerHasCars  = errs.Conflict("owner still has cars or ownership history")
+)
+
+func (db *Postgres) GetOwners(ctx context.Context, q *types.GetOwnerQuery) ([]*People, error) {
+	var sb strings.Builder
+
+	sb.WriteString("SELECT id, name, surname, patronymic FROM people ")
+
+	where, args := ownerFilter(q)
+	sb.WriteString(where)
+
+	sb.WriteString("ORDER BY id ")
+
+	if q.Limit > 0 {
+		args = append(args, q.Limit)
+		sb.WriteString(fmt.Sprintf("LIMIT $%d ", len(args)))
+	}
+	if q.Offset > 0 {
+		args = append(args, q.Offset)
+		sb.WriteString(fmt.Sprintf("OFFSET $%d", len(args)))
+	}
+
+	rows, err := db.db.Query(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	defer rows.Close()
+
+	owners := make([]*People, 0)
+
+	for rows.Next() {
+		p := new(People)
+
+		err = rows.Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic)
+		if err != nil {
+			return nil, fmt.Errorf("database: %w", err)
+		}
+
+		owners = append(owners, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	return owners, nil
+}
+
+// CountOwners returns the number of owners matching the filters of q,
+// ignoring its limit and offset.
+func (db *Postgres) CountOwners(ctx context.Context, q *types.GetOwnerQuery) (int, error) {
+	where, args := ownerFilter(q)
+
+	var total int
+
+	err := db.db.QueryRow(ctx, "SELECT count(*) FROM people "+where, args...).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("database: %w", err)
+	}
+
+	return total, nil
+}
+
+func ownerFilter(q *types.GetOwnerQuery) (string, []any) {
+	var sb strings.Builder
+
+	sb.WriteString("WHERE true ")
+
+	var args []any
+
+	if q.Name != "" {
+		args = append(args, q.Name)
+		sb.WriteString(fmt.Sprintf("AND name = $%d ", len(args)))
+	}
+	if q.Surname != "" {
+		args = append(args, q.Surname)
+		sb.WriteString(fmt.Sprintf("AND surname = $%d ", len(args)))
+	}
+	if q.Patronymic != "" {
+		args = append(args, q.Patronymic)
+		sb.WriteString(fmt.Sprintf("AND patronymic = $%d ", len(args)))
+	}
+
+	return sb.String(), args
+}
+
+func (db *Postgres) GetOwnerByID(ctx context.Context, id int) (People, error) {
+	owner, err := getOwnerByID(ctx, db.db, id)
+	if errors.Is(err, errs.ErrNotFound) {
+		return People{}, err
+	}
+	if err != nil {
+		return People{}, fmt.Errorf("database: %w", err)
+	}
+
+	return owner, nil
+}
+
+func getOwnerByID(ctx context.Context, q querier, id int) (People, error) {
+	query := `SELECT id, name, surname, patronymic FROM people WHERE id = $1;`
+
+	var p People
+
+	err := q.QueryRow(ctx, query, id).Scan(&p.ID, &p.Name, &p.Surname, &p.Patronymic)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return People{}, errOwnerNotFound
+	}
+	if err != nil {
+		return People{}, err
+	}
+
+	return p, nil
+}
+
+func (db *Postgres) CreateOwner(ctx context.Context, p People) (People, error) {
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		stmt := `
+		INSERT INTO people (name, surname, patronymic)
+		VALUES ($1, $2, $3)
+		RETURNING id;`
+
+		err := tx.QueryRow(ctx, stmt, p.Name, p.Surname, p.Patronymic).Scan(&p.ID)
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, EntityOwner, p.ID, ActionCreate, nil, p)
+	})
+	if isUniqueViolation(err) {
+		return People{}, errOwnerExists
+	}
+	if err != nil {
+		return People{}, fmt.Errorf("database: %w", err)
+	}
+
+	return p, nil
+}
+
+// UpdateOwner changes the non-empty fields of the owner, which affects all
+// of their cars.
+func (db *Postgres) UpdateOwner(ctx context.Context, p People) (People, error) {
+	var sb strings.Builder
+
+	sb.WriteString("UPDATE people SET ")
+
+	var args []any
+
+	if p.Name != "" {
+		args = append(args, p.Name)
+		sb.WriteString(fmt.Sprintf("name = $%d, ", len(args)))
+	}
+	if p.Surname != "" {
+		args = append(args, p.Surname)
+		sb.WriteString(fmt.Sprintf("surname = $%d, ", len(args)))
+	}
+	if p.Patronymic != "" {
+		args = append(args, p.Patronymic)
+		sb.WriteString(fmt.Sprintf("patronymic = $%d, ", len(args)))
+	}
+
+	if len(args) == 0 {
+		return db.GetOwnerByID(ctx, p.ID)
+	}
+
+	args = append(args, p.ID)
+	stmt := strings.TrimSuffix(sb.String(), ", ") + fmt.Sprintf(" WHERE id = $%d RETURNING id, name, surname, patronymic;", len(args))
+
+	var owner People
+
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		before, err := getOwnerByID(ctx, tx, p.ID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(ctx, stmt, args...).Scan(&owner.ID, &owner.Name, &owner.Surname, &owner.Patronymic)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errOwnerNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		// the owner is part of every car they own, so their ETags must change
+		rows, err := tx.Query(ctx, `UPDATE cars SET updated_at = now(), version = version + 1 WHERE owner_id = $1 RETURNING car_id;`, p.ID)
+		if err != nil {
+			return err
+		}
+
+		carIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+		if err != nil {
+			return err
+		}
+
+		for _, carID := range carIDs {
+			err = writeAudit(ctx, tx, EntityCar, carID, ActionUpdate, map[string]any{"owner": before}, map[string]any{"owner": owner})
+			if err != nil {
+				return err
+			}
+		}
+
+		return writeAudit(ctx, tx, EntityOwner, p.ID, ActionUpdate, before, owner)
+	})
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return People{}, err
+	}
+	if isUniqueViolation(err) {
+		return People{}, errOwnerExists
+	}
+	if err != nil {
+		return People{}, fmt.Errorf("database: %w", err)
+	}
+
+	return owner, nil
+}
+
+// MergeOwners moves the cars of every source owner to the target owner and
+// deletes the source owners.
+func (db *Postgres) MergeOwners(ctx context.Context, targetID int, sourceIDs []int) (People, error) {
+	var owner People
+
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		var err error
+
+		owner, err = getOwnerByID(ctx, tx, targetID)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range sourceIDs {
+			if id == targetID {
+				continue
+			}
+
+			source, err := getOwnerByID(ctx, tx, id)
+			if err != nil {
+				return err
+			}
+
+			rows, err := tx.Query(ctx, `UPDATE cars SET owner_id = $1, updated_at = now(), version = version + 1 WHERE owner_id = $2 RETURNING car_id;`, targetID, id)
+			if err != nil {
+				return err
+			}
+
+			carIDs, err := pgx.CollectRows(rows, pgx.RowTo[int])
+			if err != nil {
+				return err
+			}
+
+			for _, carID := range carIDs {
+				err = writeAudit(ctx, tx, EntityCar, carID, ActionMerge, map[string]any{"owner": source}, map[string]any{"owner": owner})
+				if err != nil {
+					return err
+				}
+			}
+
+			_, err = tx.Exec(ctx, `UPDATE ownership_history SET owner_id = $1 WHERE owner_id = $2;`, targetID, id)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, `DELETE FROM people WHERE id = $1;`, id)
+			if err != nil {
+				return err
+			}
+
+			err = writeAudit(ctx, tx, EntityOwner, id, ActionMerge, source, map[string]any{"mergedInto": targetID})
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return People{}, err
+	}
+	if err != nil {
+		return People{}, fmt.Errorf("database: %w", err)
+	}
+
+	return owner, nil
+}
+
+// DeleteOwner deletes an owner who has never owned a car.
+func (db *Postgres) DeleteOwner(ctx context.Context, id int) error {
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		before, err := getOwnerByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `DELETE FROM people WHERE id = $1;`, id)
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, EntityOwner, id, ActionDelete, before, nil)
+	})
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return err
+	}
+	if isForeignKeyViolation(err) {
+		return errOwnerHasCars
+	}
+	if err != nil {
+		return fmt.Errorf("database: %w", err)
+	}
+
+	return nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
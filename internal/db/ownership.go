@@ -0,0 +1,188 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/jackc/pgx/v5"
+)
+
+// Ownership is a period during which the owner owned the car. To is nil for
+// the current owner.
+type Ownership struct {
+	Owner People     `json:"owner"`
+	From  time.Time  `json:"from"`
+	To    *time.Time `json:"to,omitempty"`
+}
+
+// Transfer describes a change of the car's owner. Either OwnerID of an
+// existing person or the Owner data is set.
+type Transfer struct {
+	CarID   int
+	OwnerID int
+	Owner   People
+	At      time.Time
+}
+
+var errSameOwner = errs.Conflict("car already belongs to this owner")
+
+// TransferCar assigns the car to a new or existing owner, closing the current
+// ownership period at t.At and opening a new one.
+func (db *Postgres) TransferCar(ctx context.Context, t Transfer) (Car, error) {
+	var car Car
+
+	err := pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		var currentID int
+
+		err := tx.QueryRow(ctx, `SELECT owner_id FROM cars WHERE car_id = $1 AND deleted_at IS NULL FOR UPDATE;`, t.CarID).Scan(&currentID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errCarNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		before, err := getCarByID(ctx, tx, t.CarID)
+		if err != nil {
+			return err
+		}
+
+		ownerID := t.OwnerID
+
+		if ownerID != 0 {
+			_, err = getOwnerByID(ctx, tx, ownerID)
+		} else {
+			ownerID, err = upsertOwner(ctx, tx, t.Owner)
+		}
+		if err != nil {
+			return err
+		}
+
+		if ownerID == currentID {
+			return errSameOwner
+		}
+
+		err = recordOwnership(ctx, tx, t.CarID, ownerID, t.At)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx, `UPDATE cars SET owner_id = $1, updated_at = $2, version = version + 1 WHERE car_id = $3;`, ownerID, time.Now(), t.CarID)
+		if err != nil {
+			return err
+		}
+
+		car, err = getCarByID(ctx, tx, t.CarID)
+		if err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, EntityCar, t.CarID, ActionTransfer, before, car)
+	})
+
+	var domainErr *errs.Error
+	if errors.As(err, &domainErr) {
+		return Car{}, err
+	}
+	if err != nil {
+		return Car{}, fmt.Errorf("database: %w", err)
+	}
+
+	return car, nil
+}
+
+// GetOwnership returns the chain of the car's owners, newest first. When at is
+// not zero only the period covering that moment is returned.
+func (db *Postgres) GetOwnership(ctx context.Context, carID int, at time.Time) ([]*Ownership, error) {
+	var exists bool
+
+	err := db.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM cars WHERE car_id = $1);`, carID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	if !exists {
+		return nil, errCarNotFound
+	}
+
+	query := `
+	SELECT p.id, p.name, p.surname, p.patronymic, h.owned_from, h.owned_to
+	FROM ownership_history h
+	JOIN people p ON p.id = h.owner_id
+	WHERE h.car_id = $1
+	AND ($2::timestamptz IS NULL OR (h.owned_from <= $2 AND (h.owned_to IS NULL OR h.owned_to > $2)))
+	ORDER BY h.owned_from DESC, h.id DESC;`
+
+	var atArg *time.Time
+	if !at.IsZero() {
+		atArg = &at
+	}
+
+	rows, err := db.db.Query(ctx, query, carID, atArg)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	defer rows.Close()
+
+	history := make([]*Ownership, 0)
+
+	for rows.Next() {
+		o := new(Ownership)
+
+		err = rows.Scan(&o.Owner.ID, &o.Owner.Name, &o.Owner.Surname, &o.Owner.Patronymic, &o.From, &o.To)
+		if err != nil {
+			return nil, fmt.Errorf("database: %w", err)
+		}
+
+		history = append(history, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+
+	return history, nil
+}
+
+// recordOwnership closes the open ownership period of the car at from and
+// opens a new one for ownerID. Nothing changes if ownerID is already the
+// current owner.
+func recordOwnership(ctx context.Context, tx pgx.Tx, carID, ownerID int, from time.Time) error {
+	var (
+		currentID   int
+		currentFrom time.Time
+	)
+
+	query := `
+	SELECT owner_id, owned_from FROM ownership_history
+	WHERE car_id = $1 AND owned_to IS NULL
+	FOR UPDATE;`
+
+	err := tx.QueryRow(ctx, query, carID).Scan(&currentID, &currentFrom)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+	case err != nil:
+		return err
+	case currentID == ownerID:
+		return nil
+	case from.Before(currentFrom):
+		return errs.Validation("transfer date is before the current owner got the car")
+	default:
+		_, err = tx.Exec(ctx, `
+		UPDATE ownership_history SET owned_to = $1
+		WHERE car_id = $2 AND owned_to IS NULL;`, from, carID)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `
+	INSERT INTO ownership_history (car_id, owner_id, owned_from)
+	VALUES ($1, $2, $3);`, carID, ownerID, from)
+
+	return err
+}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// PurgeDeletedCars removes the cars deleted before the given time along with
+// their ownership history. Owners of these cars who are left without any car
+// or history are removed too, owners who never had a car are kept. Both are
+// recorded in the audit log.
+func (db *Postgres) PurgeDeletedCars(ctx context.Context, before time.Time) (cars, owners int, err error) {
+	err = pgx.BeginFunc(ctx, db.db, func(tx pgx.Tx) error {
+		// the history is read before the cascade deletes it
+		query := `
+		WITH purged AS (
+			DELETE FROM cars WHERE deleted_at < $1
+			RETURNING car_id, owner_id, reg_num
+		), owners AS (
+			SELECT owner_id FROM purged
+			UNION
+			SELECT h.owner_id FROM ownership_history h JOIN purged p ON p.car_id = h.car_id
+		), audit AS (
+			INSERT INTO audit_events (entity, entity_id, action, actor, before)
+			SELECT $2, car_id, $3, $4, jsonb_build_object('regNum', reg_num) FROM purged
+		)
+		SELECT (SELECT count(*) FROM purged), coalesce(array_agg(owner_id), '{}') FROM owners;`
+
+		var ownerIDs []int
+
+		err := tx.QueryRow(ctx, query, before, EntityCar, ActionPurge, systemActor).Scan(&cars, &ownerIDs)
+		if err != nil {
+			return err
+		}
+
+		if len(ownerIDs) == 0 {
+			return nil
+		}
+
+		stmt := `
+		WITH deleted AS (
+			DELETE FROM people p
+			WHERE p.id = ANY($1)
+				AND NOT EXISTS (SELECT 1 FROM cars c WHERE c.owner_id = p.id)
+				AND NOT EXISTS (SELECT 1 FROM ownership_history h WHERE h.owner_id = p.id)
+			RETURNING id, name, surname, patronymic
+		)
+		INSERT INTO audit_events (entity, entity_id, action, actor, before)
+		SELECT $2, id, $3, $4, jsonb_build_object('name', name, 'surname', surname, 'patronymic', patronymic)
+		FROM deleted;`
+
+		tag, err := tx.Exec(ctx, stmt, ownerIDs, EntityOwner, ActionPurge, systemActor)
+		if err != nil {
+			return err
+		}
+
+		owners = int(tag.RowsAffected())
+
+		return nil
+	})
+	if err != nil {
+		return 0, 0, fmt.Errorf("database: %w", err)
+	}
+
+	return cars, owners, nil
+}
+
+// RunPurge calls PurgeDeletedCars every interval for cars deleted more than
+// retention ago until ctx is done.
+func (db *Postgres) RunPurge(ctx context.Context, interval, retention time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		cars, owners, err := db.PurgeDeletedCars(ctx, time.Now().Add(-retention))
+		switch {
+		case err != nil:
+			log.Error(err)
+		case cars > 0:
+			log.Infof("purged %d deleted cars and %d owners", cars, owners)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
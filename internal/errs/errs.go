@@ -0,0 +1,94 @@
+// Package errs contains the domain errors shared by storage, the info API
+// client and the http layer. Every error has a kind which callers check with
+// errors.Is and which the router maps to an http status and a stable code.
+package errs
+
+import "errors"
+
+// Error kinds.
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrConflict            = errors.New("conflict")
+	ErrValidation          = errors.New("validation failed")
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	ErrPreconditionFailed  = errors.New("precondition failed")
+)
+
+// Error is a domain error. Message is safe to show to clients, the wrapped
+// cause is only for logs.
+type Error struct {
+	Kind    error
+	Message string
+	Fields  []FieldError
+	Err     error
+}
+
+// FieldError describes an invalid field of a request.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err != nil {
+		return []error{e.Kind, e.Err}
+	}
+
+	return []error{e.Kind}
+}
+
+func NotFound(msg string) error {
+	return &Error{Kind: ErrNotFound, Message: msg}
+}
+
+func Conflict(msg string) error {
+	return &Error{Kind: ErrConflict, Message: msg}
+}
+
+func Validation(msg string) error {
+	return &Error{Kind: ErrValidation, Message: msg}
+}
+
+// InvalidFields is a validation error listing every invalid field.
+func InvalidFields(fields []FieldError) error {
+	return &Error{Kind: ErrValidation, Message: "request has invalid fields", Fields: fields}
+}
+
+// PreconditionFailed is returned when a conditional change finds the entity
+// in another state than the client expected.
+func PreconditionFailed(msg string) error {
+	return &Error{Kind: ErrPreconditionFailed, Message: msg}
+}
+
+func Unavailable(msg string, err error) error {
+	return &Error{Kind: ErrUpstreamUnavailable, Message: msg, Err: err}
+}
+
+// Fields returns the invalid fields of a validation error.
+func Fields(err error) []FieldError {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Fields
+	}
+
+	return nil
+}
+
+// Message returns the client safe message of a domain error and ok=false for
+// any other error.
+func Message(err error) (msg string, ok bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message, true
+	}
+
+	return "", false
+}
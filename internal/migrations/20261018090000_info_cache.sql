@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE info_cache (
+    reg_num TEXT PRIMARY KEY,
+    car JSONB,
+    not_found BOOLEAN NOT NULL DEFAULT false,
+    expires_at timestamp with time zone NOT NULL
+);
+
+CREATE INDEX info_cache_expires_at_idx ON info_cache (expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE info_cache;
+
+-- +goose StatementEnd
@@ -0,0 +1,53 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE cars
+    ADD COLUMN reg_num_key TEXT GENERATED ALWAYS AS (upper(regexp_replace(reg_num, '\s', '', 'g'))) STORED;
+
+-- keep the latest row for every plate, the rest go to cars_duplicates for review
+CREATE TABLE cars_duplicates (
+    car_id BIGINT PRIMARY KEY,
+    reg_num TEXT NOT NULL,
+    mark TEXT NOT NULL,
+    model TEXT NOT NULL,
+    year INTEGER,
+    owner_id BIGINT,
+    created_at timestamp with time zone,
+    updated_at timestamp with time zone,
+    kept_car_id BIGINT NOT NULL,
+    removed_at timestamp with time zone default now()
+);
+
+WITH ranked AS (
+    SELECT car_id,
+           first_value(car_id) OVER w AS kept_car_id,
+           row_number() OVER w AS rn
+    FROM cars
+    WINDOW w AS (PARTITION BY reg_num_key ORDER BY updated_at DESC NULLS LAST, car_id DESC)
+)
+INSERT INTO cars_duplicates (car_id, reg_num, mark, model, year, owner_id, created_at, updated_at, kept_car_id)
+SELECT c.car_id, c.reg_num, c.mark, c.model, c.year, c.owner_id, c.created_at, c.updated_at, r.kept_car_id
+FROM cars c
+JOIN ranked r ON r.car_id = c.car_id
+WHERE r.rn > 1;
+
+DELETE FROM cars WHERE car_id IN (SELECT car_id FROM cars_duplicates);
+
+CREATE UNIQUE INDEX cars_reg_num_key_idx ON cars (reg_num_key);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX cars_reg_num_key_idx;
+
+INSERT INTO cars (car_id, reg_num, mark, model, year, owner_id, created_at, updated_at)
+SELECT car_id, reg_num, mark, model, year, owner_id, created_at, updated_at
+FROM cars_duplicates;
+
+DROP TABLE cars_duplicates;
+
+ALTER TABLE cars DROP COLUMN reg_num_key;
+
+-- +goose StatementEnd
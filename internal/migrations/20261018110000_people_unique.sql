@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+UPDATE people SET patronymic = '' WHERE patronymic IS NULL;
+
+ALTER TABLE people
+    ALTER COLUMN patronymic SET DEFAULT '',
+    ALTER COLUMN patronymic SET NOT NULL;
+
+-- merge duplicated owners into the oldest row before adding the unique key
+WITH dups AS (
+    SELECT id, min(id) OVER (PARTITION BY name, surname, patronymic) AS keep_id
+    FROM people
+)
+UPDATE cars c SET owner_id = d.keep_id
+FROM dups d
+WHERE c.owner_id = d.id AND d.id <> d.keep_id;
+
+DELETE FROM people p
+USING people keep
+WHERE keep.name = p.name AND keep.surname = p.surname AND keep.patronymic = p.patronymic
+  AND keep.id < p.id;
+
+ALTER TABLE people ADD CONSTRAINT people_identity_key UNIQUE (name, surname, patronymic);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE people DROP CONSTRAINT people_identity_key;
+
+ALTER TABLE people
+    ALTER COLUMN patronymic DROP NOT NULL,
+    ALTER COLUMN patronymic DROP DEFAULT;
+
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+CREATE TABLE ownership_history (
+    id BIGSERIAL PRIMARY KEY,
+    car_id BIGINT NOT NULL REFERENCES cars(car_id) ON DELETE CASCADE,
+    owner_id BIGINT NOT NULL REFERENCES people(id),
+    owned_from timestamp with time zone NOT NULL,
+    owned_to timestamp with time zone,
+    CHECK (owned_to IS NULL OR owned_to >= owned_from)
+);
+
+-- a car has exactly one current owner
+CREATE UNIQUE INDEX ownership_history_current_idx ON ownership_history (car_id) WHERE owned_to IS NULL;
+CREATE INDEX ownership_history_owner_id_idx ON ownership_history (owner_id);
+
+INSERT INTO ownership_history (car_id, owner_id, owned_from)
+SELECT car_id, owner_id, coalesce(created_at, now())
+FROM cars
+WHERE owner_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE ownership_history;
+
+-- +goose StatementEnd
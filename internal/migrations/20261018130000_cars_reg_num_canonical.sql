@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- canonical plates are upper case Cyrillic without spaces, diplomatic plates
+-- keep their Latin CD, D and T, see internal/regnum
+CREATE TEMPORARY TABLE canonical_reg_nums ON COMMIT DROP AS
+SELECT car_id,
+       translate(upper(regexp_replace(reg_num, '[\s-]', '', 'g')), 'ABEKMHOPCTYX', 'АВЕКМНОРСТУХ') AS reg_num
+FROM cars;
+
+UPDATE canonical_reg_nums
+SET reg_num = translate(reg_num, 'СТ', 'CT')
+WHERE reg_num ~ '^\d{3}(СD|D|Т)\d{3,6}$';
+
+-- plates which only differed by the alphabet are duplicates, keep the latest
+WITH ranked AS (
+    SELECT c.car_id,
+           first_value(c.car_id) OVER w AS kept_car_id,
+           row_number() OVER w AS rn
+    FROM cars c
+    JOIN canonical_reg_nums n ON n.car_id = c.car_id
+    WINDOW w AS (PARTITION BY n.reg_num ORDER BY c.updated_at DESC NULLS LAST, c.car_id DESC)
+)
+INSERT INTO cars_duplicates (car_id, reg_num, mark, model, year, owner_id, created_at, updated_at, kept_car_id)
+SELECT c.car_id, c.reg_num, c.mark, c.model, c.year, c.owner_id, c.created_at, c.updated_at, r.kept_car_id
+FROM cars c
+JOIN ranked r ON r.car_id = c.car_id
+WHERE r.rn > 1;
+
+DELETE FROM cars WHERE car_id IN (SELECT car_id FROM cars_duplicates);
+
+UPDATE cars c
+SET reg_num = n.reg_num
+FROM canonical_reg_nums n
+WHERE n.car_id = c.car_id AND c.reg_num <> n.reg_num;
+
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- the original spelling of the plates is not kept, canonical plates stay
@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- the parts of canonical plates, see internal/regnum for the formats. NULL for
+-- plates which match none of them.
+ALTER TABLE cars
+    ADD COLUMN series TEXT GENERATED ALWAYS AS (CASE
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]\d{3}[АВЕКМНОРСТУХ]{2}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 1, 1) || substr(reg_num, 5, 2)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 1, 2)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{4}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 1, 2)
+        WHEN reg_num ~ '^\d{3}CD\d(\d{2}|[179]\d{2})$' THEN 'CD'
+        WHEN reg_num ~ '^\d{3}[DT]\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 4, 1)
+    END) STORED,
+    ADD COLUMN number TEXT GENERATED ALWAYS AS (CASE
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]\d{3}[АВЕКМНОРСТУХ]{2}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 2, 3)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 3, 3)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{4}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 3, 4)
+        WHEN reg_num ~ '^\d{3}CD\d(\d{2}|[179]\d{2})$' THEN substr(reg_num, 1, 3) || substr(reg_num, 6, 1)
+        WHEN reg_num ~ '^\d{3}[DT]\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 1, 3) || substr(reg_num, 5, 3)
+    END) STORED,
+    ADD COLUMN region TEXT GENERATED ALWAYS AS (CASE
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]\d{3}[АВЕКМНОРСТУХ]{2}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 7)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 6)
+        WHEN reg_num ~ '^[АВЕКМНОРСТУХ]{2}\d{4}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 7)
+        WHEN reg_num ~ '^\d{3}CD\d(\d{2}|[179]\d{2})$' THEN substr(reg_num, 7)
+        WHEN reg_num ~ '^\d{3}[DT]\d{3}(\d{2}|[179]\d{2})$' THEN substr(reg_num, 8)
+    END) STORED;
+
+CREATE INDEX cars_region_idx ON cars (region);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX cars_region_idx;
+
+ALTER TABLE cars
+    DROP COLUMN series,
+    DROP COLUMN number,
+    DROP COLUMN region;
+
+-- +goose StatementEnd
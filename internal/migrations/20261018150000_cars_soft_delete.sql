@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+ALTER TABLE cars ADD COLUMN deleted_at timestamp with time zone;
+
+-- a deleted car doesn't hold on to its plate, restoring it fails if the plate
+-- was taken in the meantime
+DROP INDEX cars_reg_num_key_idx;
+CREATE UNIQUE INDEX cars_reg_num_key_idx ON cars (reg_num_key) WHERE deleted_at IS NULL;
+
+CREATE INDEX cars_deleted_at_idx ON cars (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+-- deleted cars are purged for good, their plates may be taken by live cars
+DELETE FROM cars WHERE deleted_at IS NOT NULL;
+
+DROP INDEX cars_deleted_at_idx;
+DROP INDEX cars_reg_num_key_idx;
+CREATE UNIQUE INDEX cars_reg_num_key_idx ON cars (reg_num_key);
+
+ALTER TABLE cars DROP COLUMN deleted_at;
+
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- audit_events outlives the rows it describes, so there are no foreign keys
+CREATE TABLE audit_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at timestamp with time zone NOT NULL DEFAULT now(),
+    entity TEXT NOT NULL,
+    entity_id BIGINT NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    request_id TEXT NOT NULL DEFAULT '',
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX audit_events_entity_idx ON audit_events (entity, entity_id, id);
+CREATE INDEX audit_events_occurred_at_idx ON audit_events (occurred_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TABLE audit_events;
+
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- version is bumped by every change of the car and guards PATCH against lost
+-- updates
+ALTER TABLE cars ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+ALTER TABLE cars DROP COLUMN version;
+
+-- +goose StatementEnd
@@ -0,0 +1,134 @@
+// Package regnum canonicalizes and validates Russian registration plates.
+//
+// Plates only use the twelve Cyrillic letters which look like Latin ones, so
+// clients often type them in Latin. The canonical form is upper case Cyrillic
+// without spaces, except for the Latin CD, D and T of diplomatic plates. The
+// info service knows plates in Latin, see Latin.
+package regnum
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+// Format is the kind of a plate.
+type Format string
+
+const (
+	// Civilian plates look like А123ВС77.
+	Civilian Format = "civilian"
+	// Taxi plates look like АВ12377.
+	Taxi Format = "taxi"
+	// Trailer plates look like АВ123477.
+	Trailer Format = "trailer"
+	// Diplomatic plates look like 001CD177 or 001D12377.
+	Diplomatic Format = "diplomatic"
+)
+
+// ErrInvalid is returned for plates which match none of the formats.
+var ErrInvalid = errs.Validation("must be a civilian, taxi, trailer or diplomatic registration number")
+
+// latinToCyrillic folds Latin look-alikes into the Cyrillic plate letters.
+var latinToCyrillic = strings.NewReplacer(
+	"A", "А", "B", "В", "E", "Е", "K", "К", "M", "М", "H", "Н",
+	"O", "О", "P", "Р", "C", "С", "T", "Т", "Y", "У", "X", "Х",
+)
+
+// cyrillicToLatin spells the Cyrillic plate letters with their Latin look-alikes.
+var cyrillicToLatin = strings.NewReplacer(
+	"А", "A", "В", "B", "Е", "E", "К", "K", "М", "M", "Н", "H",
+	"О", "O", "Р", "P", "С", "C", "Т", "T", "У", "Y", "Х", "X",
+)
+
+// diplomaticLetters restores the Latin letters of diplomatic plates.
+var diplomaticLetters = strings.NewReplacer("С", "C", "Т", "T")
+
+// Plate is a canonical registration number split into its parts.
+type Plate struct {
+	RegNum string
+	Format Format
+	// Series are the letters, CD, D or T for diplomatic plates
+	Series string
+	// Number are the digits before the region, for diplomatic plates the
+	// mission code followed by the sequence number
+	Number string
+	// Region is the 2 or 3 digit region code
+	Region string
+}
+
+// The patterns capture the series, number and region. Three digit region
+// codes all start with 1, 7 or 9, which tells a taxi plate with a three digit
+// region from a trailer plate with a two digit one. The generated columns of
+// the cars table follow the same rules.
+var formats = []struct {
+	format  Format
+	pattern *regexp.Regexp
+	parts   func(m []string) (series, number, region string)
+}{
+	{Civilian, regexp.MustCompile(`^([АВЕКМНОРСТУХ])(\d{3})([АВЕКМНОРСТУХ]{2})(\d{2}|[179]\d{2})$`), func(m []string) (string, string, string) {
+		return m[1] + m[3], m[2], m[4]
+	}},
+	{Taxi, regexp.MustCompile(`^([АВЕКМНОРСТУХ]{2})(\d{3})(\d{2}|[179]\d{2})$`), func(m []string) (string, string, string) {
+		return m[1], m[2], m[3]
+	}},
+	{Trailer, regexp.MustCompile(`^([АВЕКМНОРСТУХ]{2})(\d{4})(\d{2}|[179]\d{2})$`), func(m []string) (string, string, string) {
+		return m[1], m[2], m[3]
+	}},
+	{Diplomatic, regexp.MustCompile(`^(\d{3})(CD)(\d)(\d{2}|[179]\d{2})$`), func(m []string) (string, string, string) {
+		return m[2], m[1] + m[3], m[4]
+	}},
+	{Diplomatic, regexp.MustCompile(`^(\d{3})([DT])(\d{3})(\d{2}|[179]\d{2})$`), func(m []string) (string, string, string) {
+		return m[2], m[1] + m[3], m[4]
+	}},
+}
+
+// foldedDiplomatic matches a diplomatic plate whose letters were folded into
+// Cyrillic.
+var foldedDiplomatic = regexp.MustCompile(`^\d{3}(СD\d|[DТ]\d{3})\d{2,3}$`)
+
+// Normalize returns the canonical form of s without validating it.
+func Normalize(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' {
+			return -1
+		}
+
+		return unicode.ToUpper(r)
+	}, s)
+
+	s = latinToCyrillic.Replace(s)
+
+	if foldedDiplomatic.MatchString(s) {
+		s = diplomaticLetters.Replace(s)
+	}
+
+	return s
+}
+
+// Latin returns the canonical form of s spelled in Latin letters, e.g.
+// X123XX150, the way the info service expects plates.
+func Latin(s string) string {
+	return cyrillicToLatin.Replace(Normalize(s))
+}
+
+// Parse normalizes s and splits it into parts, or returns ErrInvalid.
+func Parse(s string) (Plate, error) {
+	s = Normalize(s)
+
+	for _, f := range formats {
+		m := f.pattern.FindStringSubmatch(s)
+		if m == nil {
+			continue
+		}
+
+		p := Plate{RegNum: s, Format: f.format}
+		p.Series, p.Number, p.Region = f.parts(m)
+
+		return p, nil
+	}
+
+	return Plate{RegNum: s}, ErrInvalid
+}
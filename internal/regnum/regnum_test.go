@@ -0,0 +1,231 @@
+package regnum
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/basedalex/effective-mobile-test/internal/migrations"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "А123ВС77", want: "А123ВС77"},
+		{in: "x123xx150", want: "Х123ХХ150"},
+		{in: "X 123 XX 150", want: "Х123ХХ150"},
+		{in: "а123-вс-77", want: "А123ВС77"},
+		{in: "ab12377", want: "АВ12377"},
+		{in: "001cd177", want: "001CD177"},
+		{in: "001СD177", want: "001CD177"},
+		{in: "001d12377", want: "001D12377"},
+		{in: "002т123177", want: "002T123177"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLatin(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Х123ХХ150", want: "X123XX150"},
+		{in: "x123xx150", want: "X123XX150"},
+		{in: "а 001 аа 77", want: "A001AA77"},
+		{in: "001CD177", want: "001CD177"},
+	}
+
+	for _, tt := range tests {
+		if got := Latin(tt.in); got != tt.want {
+			t.Errorf("Latin(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+var parseTests = []struct {
+	in   string
+	want Plate
+}{
+	{in: "А123ВС77", want: Plate{RegNum: "А123ВС77", Format: Civilian, Series: "АВС", Number: "123", Region: "77"}},
+	{in: "x123xx150", want: Plate{RegNum: "Х123ХХ150", Format: Civilian, Series: "ХХХ", Number: "123", Region: "150"}},
+	{in: "АВ12377", want: Plate{RegNum: "АВ12377", Format: Taxi, Series: "АВ", Number: "123", Region: "77"}},
+	{in: "АВ123777", want: Plate{RegNum: "АВ123777", Format: Taxi, Series: "АВ", Number: "123", Region: "777"}},
+	{in: "АВ123477", want: Plate{RegNum: "АВ123477", Format: Trailer, Series: "АВ", Number: "1234", Region: "77"}},
+	{in: "АВ1234777", want: Plate{RegNum: "АВ1234777", Format: Trailer, Series: "АВ", Number: "1234", Region: "777"}},
+	{in: "001CD177", want: Plate{RegNum: "001CD177", Format: Diplomatic, Series: "CD", Number: "0011", Region: "77"}},
+	{in: "001D12377", want: Plate{RegNum: "001D12377", Format: Diplomatic, Series: "D", Number: "001123", Region: "77"}},
+	{in: "001T123177", want: Plate{RegNum: "001T123177", Format: Diplomatic, Series: "T", Number: "001123", Region: "177"}},
+}
+
+var invalidPlates = []string{
+	"",
+	"А123ВС7",
+	"А123ВС277",
+	"Б123ВС77",
+	"А12ВС77",
+	"АВС12377",
+	"001ХХ177",
+	"123",
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range parseTests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q) error: %s", tt.in, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range invalidPlates {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+// TestMigrationMatchesParse evaluates the generated columns of the
+// reg_num_parts migration and checks they split plates the way Parse does.
+func TestMigrationMatchesParse(t *testing.T) {
+	sql, err := migrations.FS.ReadFile("20261018140000_cars_reg_num_parts.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns := generatedColumns(t, string(sql))
+
+	for _, name := range []string{"series", "number", "region"} {
+		if len(columns[name]) == 0 {
+			t.Fatalf("no cases for the %s column", name)
+		}
+	}
+
+	plates := invalidPlates
+	for _, tt := range parseTests {
+		plates = append(plates, tt.want.RegNum)
+	}
+
+	for _, regNum := range plates {
+		want, err := Parse(regNum)
+		if err != nil {
+			want = Plate{}
+		}
+
+		got := Plate{
+			Series: columns.eval("series", regNum),
+			Number: columns.eval("number", regNum),
+			Region: columns.eval("region", regNum),
+		}
+
+		if got.Series != want.Series || got.Number != want.Number || got.Region != want.Region {
+			t.Errorf("%q: migration gives %s/%s/%s, Parse gives %s/%s/%s", regNum,
+				got.Series, got.Number, got.Region, want.Series, want.Number, want.Region)
+		}
+	}
+}
+
+type sqlCase struct {
+	pattern *regexp.Regexp
+	value   []string
+}
+
+type sqlColumns map[string][]sqlCase
+
+var (
+	columnRe = regexp.MustCompile(`ADD COLUMN (\w+) TEXT GENERATED`)
+	whenRe   = regexp.MustCompile(`WHEN reg_num ~ '([^']*)' THEN (.+)$`)
+	substrRe = regexp.MustCompile(`^substr\(reg_num, (\d+)(?:, (\d+))?\)$`)
+)
+
+// generatedColumns collects the WHEN reg_num ~ '...' THEN ... cases of every
+// generated column.
+func generatedColumns(t *testing.T, sql string) sqlColumns {
+	t.Helper()
+
+	res := make(sqlColumns)
+
+	var column string
+
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+
+		if m := columnRe.FindStringSubmatch(line); m != nil {
+			column = m[1]
+			continue
+		}
+
+		m := whenRe.FindStringSubmatch(line)
+		if m == nil || column == "" {
+			continue
+		}
+
+		res[column] = append(res[column], sqlCase{
+			pattern: regexp.MustCompile(m[1]),
+			value:   strings.Split(m[2], " || "),
+		})
+	}
+
+	return res
+}
+
+// eval returns the value of the column for regNum, empty for NULL.
+func (c sqlColumns) eval(column, regNum string) string {
+	for _, cs := range c[column] {
+		if !cs.pattern.MatchString(regNum) {
+			continue
+		}
+
+		var sb strings.Builder
+
+		for _, term := range cs.value {
+			sb.WriteString(evalTerm(term, regNum))
+		}
+
+		return sb.String()
+	}
+
+	return ""
+}
+
+// evalTerm evaluates a string literal or substr(reg_num, from[, count]) with
+// postgres' 1-based character positions.
+func evalTerm(term, regNum string) string {
+	if lit, ok := strings.CutPrefix(term, "'"); ok {
+		return strings.TrimSuffix(lit, "'")
+	}
+
+	m := substrRe.FindStringSubmatch(term)
+	if m == nil {
+		panic("unsupported term " + term)
+	}
+
+	runes := []rune(regNum)
+
+	from, _ := strconv.Atoi(m[1])
+	to := len(runes)
+
+	if m[2] != "" {
+		count, _ := strconv.Atoi(m[2])
+		to = min(from-1+count, len(runes))
+	}
+
+	if from-1 >= to {
+		return ""
+	}
+
+	return string(runes[from-1 : to])
+}
@@ -0,0 +1,130 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/basedalex/effective-mobile-test/internal/validate"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// anonymousActor is recorded for requests without an X-Actor header.
+const anonymousActor = "anonymous"
+
+// auditActor passes the actor from the X-Actor header and the request id to
+// the audit log and returns the request id in the X-Request-Id header. It runs
+// after middleware.RequestID.
+func auditActor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, requestID)
+
+		actor := r.Header.Get("X-Actor")
+		if actor == "" {
+			actor = anonymousActor
+		}
+
+		next.ServeHTTP(w, r.WithContext(db.WithAuditActor(r.Context(), actor, requestID)))
+	})
+}
+
+func getAuditQuery(r *http.Request) (types.GetAuditQuery, error) {
+	var q types.GetAuditQuery
+
+	fields := validate.Query(r.URL.Query(), &q)
+	fields = append(fields, validate.Struct(&q)...)
+
+	if len(fields) > 0 {
+		return q, errs.InvalidFields(fields)
+	}
+
+	return q, nil
+}
+
+// @Summary GetAudit
+// @Tags audit
+// @Description changes of cars and owners, newest first
+// @ID get-audit
+// @Produce json
+// @Param entity query string false "car or owner"
+// @Param entityId query int false "id of the car or owner"
+// @Param action query []string false "create, update, delete, restore, transfer, merge or purge, repeat for several" collectionFormat(multi)
+// @Param actor query string false "actor"
+// @Param requestId query string false "request id"
+// @Param from query string false "RFC 3339 time or YYYY-MM-DD, inclusive"
+// @Param to query string false "RFC 3339 time or YYYY-MM-DD, exclusive"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} HTTPResponse{data=[]db.AuditEvent,pagination=Pagination}
+// @Failure 400 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/audit [get]
+func (h *Handler) getAudit(w http.ResponseWriter, r *http.Request) {
+	q, err := getAuditQuery(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	h.writeAudit(w, r, &q)
+}
+
+// @Summary GetCarHistory
+// @Tags car
+// @Description audit log of the car, newest first, also for deleted cars
+// @ID get-car-history
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param action query []string false "create, update, delete, restore, transfer, merge or purge, repeat for several" collectionFormat(multi)
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} HTTPResponse{data=[]db.AuditEvent,pagination=Pagination}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id}/history [get]
+func (h *Handler) getCarHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	q, err := getAuditQuery(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	// soft deleted cars are still found, the history of purged ones is in /audit
+	_, err = h.service.GetCarByID(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	q.Entity = db.EntityCar
+	q.EntityID = id
+
+	h.writeAudit(w, r, &q)
+}
+
+func (h *Handler) writeAudit(w http.ResponseWriter, r *http.Request, q *types.GetAuditQuery) {
+	events, err := h.audit.GetAuditEvents(r.Context(), q)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	total, err := h.audit.CountAuditEvents(r.Context(), q)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeListResponse(w, r, events, total, q.Limit, q.Offset, "")
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+)
+
+type fakeAudit struct {
+	events []*db.AuditEvent
+}
+
+func (f *fakeAudit) GetAuditEvents(_ context.Context, q *types.GetAuditQuery) ([]*db.AuditEvent, error) {
+	var events []*db.AuditEvent
+
+	for _, e := range f.events {
+		if e.Entity == q.Entity && e.EntityID == q.EntityID {
+			events = append(events, e)
+		}
+	}
+
+	return events, nil
+}
+
+func (f *fakeAudit) CountAuditEvents(ctx context.Context, q *types.GetAuditQuery) (int, error) {
+	events, err := f.GetAuditEvents(ctx, q)
+	return len(events), err
+}
+
+func TestGetCarHistory(t *testing.T) {
+	h := &Handler{
+		service: newFakeCars(db.Car{ID: 1}),
+		audit: &fakeAudit{events: []*db.AuditEvent{
+			{ID: 1, Entity: db.EntityCar, EntityID: 1, Action: db.ActionCreate},
+			{ID: 2, Entity: db.EntityCar, EntityID: 2, Action: db.ActionPurge},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		id        string
+		wantCode  int
+		wantTotal string
+	}{
+		{name: "existing car", id: "1", wantCode: http.StatusOK, wantTotal: "1"},
+		{name: "missing car", id: "2", wantCode: http.StatusNotFound},
+		{name: "bad id", id: "x", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/car/"+tt.id+"/history", nil)
+			w := httptest.NewRecorder()
+
+			h.getCarHistory(w, withID(r, tt.id))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if got := w.Header().Get("X-Total-Count"); got != tt.wantTotal {
+				t.Errorf("X-Total-Count = %q, want %q", got, tt.wantTotal)
+			}
+		})
+	}
+}
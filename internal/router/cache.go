@@ -0,0 +1,117 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+// carETag changes whenever the car is updated.
+func carETag(c db.Car) string {
+	return fmt.Sprintf(`"%d-%d"`, c.ID, c.Version)
+}
+
+// errETagMismatch is returned for If-Match headers naming another version.
+var errETagMismatch = errs.PreconditionFailed("If-Match does not match the current ETag of the car")
+
+// ifMatchVersion returns the car version required by the If-Match header, 0
+// when there is no header or it is *. If-Match uses the strong comparison, so
+// weak ETags never match.
+func ifMatchVersion(r *http.Request, id int) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	if strings.Contains(header, ",") {
+		return 0, errs.Validation("If-Match must hold a single ETag")
+	}
+
+	etagID, version, ok := parseCarETag(header)
+	if !ok || etagID != id {
+		return 0, errETagMismatch
+	}
+
+	return version, nil
+}
+
+// parseCarETag splits a strong ETag made by carETag into the car id and
+// version, anything else is rejected.
+func parseCarETag(etag string) (id, version int, ok bool) {
+	inner, found := strings.CutPrefix(etag, `"`)
+	if !found {
+		return 0, 0, false
+	}
+
+	inner, found = strings.CutSuffix(inner, `"`)
+	if !found {
+		return 0, 0, false
+	}
+
+	idPart, versionPart, found := strings.Cut(inner, "-")
+	if !found {
+		return 0, 0, false
+	}
+
+	id, idOK := positiveInt(idPart)
+	version, versionOK := positiveInt(versionPart)
+
+	return id, version, idOK && versionOK
+}
+
+// positiveInt parses a string of digits without a sign.
+func positiveInt(s string) (int, bool) {
+	if s == "" || strings.TrimLeft(s, "0123456789") != "" {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// notModified sets the ETag and Last-Modified headers and answers 304 when the
+// client's copy is still fresh. If-None-Match takes precedence over
+// If-Modified-Since as RFC 9110 requires.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, modified time.Time) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+
+		w.WriteHeader(http.StatusNotModified)
+
+		return true
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || modified.Truncate(time.Second).After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+
+	return true
+}
+
+func etagMatches(header, etag string) bool {
+	for _, v := range strings.Split(header, ",") {
+		v = strings.TrimPrefix(strings.TrimSpace(v), "W/")
+		if v == "*" || v == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr error
+	}{
+		{name: "no header", header: "", want: 0},
+		{name: "any version", header: "*", want: 0},
+		{name: "matching etag", header: `"7-3"`, want: 3},
+		{name: "surrounding spaces", header: ` "7-3" `, want: 3},
+		{name: "weak etag", header: `W/"7-3"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "list", header: `"7-3", "7-4"`, wantErr: errs.ErrValidation},
+		{name: "another car", header: `"8-3"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "trailing garbage inside", header: `"7-3xyz"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "trailing garbage outside", header: `"7-3"xyz`, wantErr: errs.ErrPreconditionFailed},
+		{name: "signed id", header: `"+7-3"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "zero version", header: `"7-0"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "unquoted", header: `7-3`, wantErr: errs.ErrPreconditionFailed},
+		{name: "no version", header: `"7"`, wantErr: errs.ErrPreconditionFailed},
+		{name: "extra part", header: `"7-3-1"`, wantErr: errs.ErrPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/api/v1/car/7", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := ifMatchVersion(r, 7)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got != tt.want {
+				t.Fatalf("version = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCarETagRoundTrip(t *testing.T) {
+	id, version, ok := parseCarETag(carETag(db.Car{ID: 12, Version: 345}))
+	if !ok || id != 12 || version != 345 {
+		t.Fatalf("parseCarETag = %d, %d, %t, want 12, 345, true", id, version, ok)
+	}
+}
@@ -0,0 +1,332 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/basedalex/effective-mobile-test/internal/validate"
+)
+
+type ownerPayload struct {
+	Name       string `json:"name" validate:"notblank,max=100"`
+	Surname    string `json:"surname" validate:"notblank,max=100"`
+	Patronymic string `json:"patronymic" validate:"max=100"`
+}
+
+// newOwnerPayload is an owner to create, unlike an update it needs the name
+// and surname.
+type newOwnerPayload struct {
+	Name       string `json:"name" validate:"required,max=100"`
+	Surname    string `json:"surname" validate:"required,max=100"`
+	Patronymic string `json:"patronymic" validate:"max=100"`
+}
+
+type mergePayload struct {
+	// OwnerIDs are merged into the owner from the path and deleted
+	OwnerIDs []int `json:"ownerIds"`
+}
+
+func getOwnerQuery(r *http.Request) (types.GetOwnerQuery, error) {
+	var q types.GetOwnerQuery
+
+	fields := validate.Query(r.URL.Query(), &q)
+	fields = append(fields, validate.Struct(&q)...)
+
+	if len(fields) > 0 {
+		return q, errs.InvalidFields(fields)
+	}
+
+	q.Name = strings.ToLower(q.Name)
+	q.Surname = strings.ToLower(q.Surname)
+	q.Patronymic = strings.ToLower(q.Patronymic)
+
+	return q, nil
+}
+
+// @Summary GetOwners
+// @Tags owner
+// @Description list owners filtered by name, surname and patronymic
+// @ID get-owners
+// @Produce json
+// @Param name query string false "name"
+// @Param surname query string false "surname"
+// @Param patronymic query string false "patronymic"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} HTTPResponse{data=[]db.People,pagination=Pagination}
+// @Failure 400 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners [get]
+func (h *Handler) getOwners(w http.ResponseWriter, r *http.Request) {
+	q, err := getOwnerQuery(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	owners, err := h.owners.GetOwners(r.Context(), &q)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	total, err := h.owners.CountOwners(r.Context(), &q)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeListResponse(w, r, owners, total, q.Limit, q.Offset, "")
+}
+
+// @Summary GetOwnerByID
+// @Tags owner
+// @Description get owner
+// @ID get-owner-by-id
+// @Produce json
+// @Param id path int true "Owner ID"
+// @Success 200 {object} HTTPResponse{data=db.People}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners/{id} [get]
+func (h *Handler) getOwnerByID(w http.ResponseWriter, r *http.Request) {
+	id, err := ownerID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	owner, err := h.owners.GetOwnerByID(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, owner)
+}
+
+// @Summary CreateOwner
+// @Tags owner
+// @Description create owner
+// @ID create-owner
+// @Accept json
+// @Produce json
+// @Param request body newOwnerPayload true "owner"
+// @Success 201 {object} HTTPResponse{data=db.People}
+// @Failure 400 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners [post]
+func (h *Handler) createOwner(w http.ResponseWriter, r *http.Request) {
+	var payload newOwnerPayload
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeErrResponse(w, errs.Validation("invalid request body: "+err.Error()))
+		return
+	}
+
+	if err = validPayload(&payload); err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	owner, err := h.owners.CreateOwner(r.Context(), db.People{
+		Name:       strings.ToLower(payload.Name),
+		Surname:    strings.ToLower(payload.Surname),
+		Patronymic: strings.ToLower(payload.Patronymic),
+	})
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusCreated, owner)
+}
+
+// @Summary UpdateOwner
+// @Tags owner
+// @Description update owner, the change applies to all of their cars
+// @ID update-owner
+// @Accept json
+// @Produce json
+// @Param id path int true "Owner ID"
+// @Param request body ownerPayload false "update options"
+// @Success 200 {object} HTTPResponse{data=db.People}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners/{id} [patch]
+func (h *Handler) updateOwner(w http.ResponseWriter, r *http.Request) {
+	id, err := ownerID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var payload ownerPayload
+
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeErrResponse(w, errs.Validation("invalid request body: "+err.Error()))
+		return
+	}
+
+	if err = validPayload(&payload); err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	owner, err := h.owners.UpdateOwner(r.Context(), db.People{
+		ID:         id,
+		Name:       strings.ToLower(payload.Name),
+		Surname:    strings.ToLower(payload.Surname),
+		Patronymic: strings.ToLower(payload.Patronymic),
+	})
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, owner)
+}
+
+// @Summary MergeOwners
+// @Tags owner
+// @Description move cars of the listed owners to this owner and delete them
+// @ID merge-owners
+// @Accept json
+// @Produce json
+// @Param id path int true "Owner ID"
+// @Param request body mergePayload true "owners to merge"
+// @Success 200 {object} HTTPResponse{data=db.People}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners/{id}/merge [post]
+func (h *Handler) mergeOwners(w http.ResponseWriter, r *http.Request) {
+	id, err := ownerID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var payload mergePayload
+
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeErrResponse(w, errs.Validation("invalid request body: "+err.Error()))
+		return
+	}
+
+	if len(payload.OwnerIDs) == 0 {
+		writeErrResponse(w, errs.Validation("ownerIds must not be empty"))
+		return
+	}
+
+	owner, err := h.owners.MergeOwners(r.Context(), id, payload.OwnerIDs)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, owner)
+}
+
+// @Summary DeleteOwner
+// @Tags owner
+// @Description delete an owner who has never owned a car
+// @ID delete-owner
+// @Produce json
+// @Param id path int true "Owner ID"
+// @Success 204
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners/{id} [delete]
+func (h *Handler) deleteOwner(w http.ResponseWriter, r *http.Request) {
+	id, err := ownerID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	err = h.owners.DeleteOwner(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusNoContent, nil)
+}
+
+// @Summary GetOwnerCars
+// @Tags owner
+// @Description list cars of the owner
+// @ID get-owner-cars
+// @Produce json
+// @Param id path int true "Owner ID"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset"
+// @Success 200 {object} HTTPResponse{data=[]db.Car,pagination=Pagination}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/owners/{id}/cars [get]
+func (h *Handler) getOwnerCars(w http.ResponseWriter, r *http.Request) {
+	id, err := ownerID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	_, err = h.owners.GetOwnerByID(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var q types.PageQuery
+
+	fields := validate.Query(r.URL.Query(), &q)
+	fields = append(fields, validate.Struct(&q)...)
+
+	if len(fields) > 0 {
+		writeErrResponse(w, errs.InvalidFields(fields))
+		return
+	}
+
+	carQuery := types.GetCarQuery{
+		OwnerID: id,
+		Limit:   q.Limit,
+		Offset:  q.Offset,
+	}
+
+	cars, err := h.service.GetCar(r.Context(), &carQuery)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	total, err := h.service.CountCars(r.Context(), &carQuery)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeListResponse(w, r, cars, total, q.Limit, q.Offset, "")
+}
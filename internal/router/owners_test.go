@@ -0,0 +1,178 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+)
+
+type fakeOwners struct {
+	ownerService
+
+	created []db.People
+}
+
+func (f *fakeOwners) CreateOwner(_ context.Context, p db.People) (db.People, error) {
+	p.ID = len(f.created) + 1
+	f.created = append(f.created, p)
+
+	return p, nil
+}
+
+func (f *fakeOwners) GetOwnerByID(_ context.Context, id int) (db.People, error) {
+	if id != 1 {
+		return db.People{}, errs.NotFound("owner not found")
+	}
+
+	return db.People{ID: 1, Name: "иван", Surname: "иванов"}, nil
+}
+
+// ownerCars records the query of the owner car list.
+type ownerCars struct {
+	fakeCars
+
+	query *types.GetCarQuery
+}
+
+func (f *ownerCars) GetCar(_ context.Context, q *types.GetCarQuery) ([]*db.Car, error) {
+	f.query = q
+	return []*db.Car{}, nil
+}
+
+func (f *ownerCars) CountCars(context.Context, *types.GetCarQuery) (int, error) {
+	return 0, nil
+}
+
+func TestCreateOwner(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantFields []string
+	}{
+		{
+			name:     "valid",
+			body:     `{"name": "Иван", "surname": "Иванов"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			name:       "missing",
+			body:       `{"patronymic": "Иванович"}`,
+			wantCode:   http.StatusBadRequest,
+			wantFields: []string{"name", "surname"},
+		},
+		{
+			name:       "blank",
+			body:       `{"name": "  ", "surname": "\t"}`,
+			wantCode:   http.StatusBadRequest,
+			wantFields: []string{"name", "surname"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owners := &fakeOwners{}
+			h := &Handler{owners: owners}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/owners", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.createOwner(w, r)
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			var res HTTPResponse
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			var fields []string
+			for _, f := range res.Fields {
+				fields = append(fields, f.Field)
+			}
+
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+
+			if tt.wantCode != http.StatusCreated && len(owners.created) > 0 {
+				t.Errorf("invalid owner was created: %+v", owners.created)
+			}
+		})
+	}
+}
+
+func TestGetOwnerCars(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		query    string
+		wantCode int
+		want     types.GetCarQuery
+	}{
+		{
+			name:     "page",
+			id:       "1",
+			query:    "?limit=10&offset=20",
+			wantCode: http.StatusOK,
+			want:     types.GetCarQuery{OwnerID: 1, Limit: 10, Offset: 20},
+		},
+		{
+			name:     "owner filters are not part of the query",
+			id:       "1",
+			query:    "?name=петр&surname=петров",
+			wantCode: http.StatusOK,
+			want:     types.GetCarQuery{OwnerID: 1},
+		},
+		{
+			name:     "bad limit",
+			id:       "1",
+			query:    "?limit=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "missing owner",
+			id:       "2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars := &ownerCars{}
+			h := &Handler{service: cars, owners: &fakeOwners{}}
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/owners/"+tt.id+"/cars"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			h.getOwnerCars(w, withID(r, tt.id))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			if cars.query == nil {
+				t.Fatal("cars weren't queried")
+			}
+
+			got := *cars.query
+			if got.OwnerID != tt.want.OwnerID || got.Limit != tt.want.Limit || got.Offset != tt.want.Offset ||
+				got.Name != nil || got.Surname != nil || got.Patronymic != nil {
+				t.Errorf("query = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
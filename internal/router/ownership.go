@@ -0,0 +1,134 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/basedalex/effective-mobile-test/internal/validate"
+)
+
+type transferPayload struct {
+	// OwnerID of an existing owner, or Owner to find or create one
+	OwnerID int              `json:"ownerId"`
+	Owner   *newOwnerPayload `json:"owner"`
+	// Date of the transfer, now if omitted
+	Date *time.Time `json:"date"`
+}
+
+// @Summary TransferCar
+// @Tags car
+// @Description assign the car to a new or existing owner and record it in the ownership history
+// @ID transfer-car
+// @Accept json
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param request body transferPayload true "new owner"
+// @Success 200 {object} HTTPResponse{data=db.Car}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id}/transfer [post]
+func (h *Handler) transferCar(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var payload transferPayload
+
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		writeErrResponse(w, errs.Validation("invalid request body: "+err.Error()))
+		return
+	}
+
+	t := db.Transfer{
+		CarID:   id,
+		OwnerID: payload.OwnerID,
+		At:      time.Now(),
+	}
+
+	switch {
+	case payload.OwnerID != 0 && payload.Owner != nil:
+		writeErrResponse(w, errs.Validation("either ownerId or owner must be set, not both"))
+		return
+	case payload.OwnerID < 0:
+		writeErrResponse(w, errs.Validation("ownerId must be a positive integer"))
+		return
+	case payload.OwnerID == 0 && payload.Owner == nil:
+		writeErrResponse(w, errs.Validation("ownerId or owner is required"))
+		return
+	case payload.Owner != nil:
+		if err = validPayload(&payload); err != nil {
+			writeErrResponse(w, err)
+			return
+		}
+
+		t.Owner = db.People{
+			Name:       strings.ToLower(payload.Owner.Name),
+			Surname:    strings.ToLower(payload.Owner.Surname),
+			Patronymic: strings.ToLower(payload.Owner.Patronymic),
+		}
+	}
+
+	if payload.Date != nil {
+		if payload.Date.After(time.Now()) {
+			writeErrResponse(w, errs.Validation("transfer date must not be in the future"))
+			return
+		}
+
+		t.At = *payload.Date
+	}
+
+	car, err := h.service.TransferCar(r.Context(), t)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, car)
+}
+
+// @Summary GetCarOwners
+// @Tags car
+// @Description chain of the car's owners, newest first
+// @ID get-car-owners
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param at query string false "only the owner at this moment, RFC 3339 or YYYY-MM-DD"
+// @Success 200 {object} HTTPResponse{data=[]db.Ownership}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id}/owners [get]
+func (h *Handler) getCarOwners(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var q types.GetOwnershipQuery
+
+	if fields := validate.Query(r.URL.Query(), &q); len(fields) > 0 {
+		writeErrResponse(w, errs.InvalidFields(fields))
+		return
+	}
+
+	history, err := h.service.GetOwnership(r.Context(), id, q.At)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, history)
+}
@@ -0,0 +1,141 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+)
+
+// transferCars records what the ownership handlers pass to the service.
+type transferCars struct {
+	fakeCars
+
+	transfer *db.Transfer
+	at       *time.Time
+}
+
+func (f *transferCars) TransferCar(_ context.Context, t db.Transfer) (db.Car, error) {
+	f.transfer = &t
+	return db.Car{ID: t.CarID}, nil
+}
+
+func (f *transferCars) GetOwnership(_ context.Context, _ int, at time.Time) ([]*db.Ownership, error) {
+	f.at = &at
+	return []*db.Ownership{}, nil
+}
+
+func TestTransferCar(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantFields []string
+		wantOwner  db.People
+	}{
+		{
+			name:      "new owner",
+			body:      `{"owner": {"name": "Петр", "surname": "Петров"}}`,
+			wantCode:  http.StatusOK,
+			wantOwner: db.People{Name: "петр", Surname: "петров"},
+		},
+		{
+			name:       "blank owner",
+			body:       `{"owner": {"name": " ", "patronymic": "петрович"}}`,
+			wantCode:   http.StatusBadRequest,
+			wantFields: []string{"owner.name", "owner.surname"},
+		},
+		{
+			name:     "no owner",
+			body:     `{}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars := &transferCars{}
+			h := &Handler{service: cars}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/car/1/transfer", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			h.transferCar(w, withID(r, "1"))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			var res HTTPResponse
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			var fields []string
+			for _, f := range res.Fields {
+				fields = append(fields, f.Field)
+			}
+
+			if strings.Join(fields, ",") != strings.Join(tt.wantFields, ",") {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				if cars.transfer != nil {
+					t.Errorf("invalid transfer was made: %+v", cars.transfer)
+				}
+				return
+			}
+
+			if cars.transfer == nil || cars.transfer.Owner != tt.wantOwner {
+				t.Errorf("transfer = %+v, want owner %+v", cars.transfer, tt.wantOwner)
+			}
+		})
+	}
+}
+
+func TestGetCarOwnersAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		wantCode int
+		wantAt   time.Time
+	}{
+		{name: "whole chain", wantCode: http.StatusOK},
+		{name: "date", query: "?at=2024-03-01", wantCode: http.StatusOK, wantAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "time", query: "?at=2024-03-01T10:00:00Z", wantCode: http.StatusOK, wantAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+		{name: "bad", query: "?at=yesterday", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cars := &transferCars{}
+			h := &Handler{service: cars}
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/car/1/owners"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			h.getCarOwners(w, withID(r, "1"))
+
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body)
+			}
+
+			if tt.wantCode != http.StatusOK {
+				if !strings.Contains(w.Body.String(), `"field":"at"`) {
+					t.Errorf("the error doesn't name the at field: %s", w.Body)
+				}
+				return
+			}
+
+			if cars.at == nil || !cars.at.Equal(tt.wantAt) {
+				t.Errorf("at = %v, want %v", cars.at, tt.wantAt)
+			}
+		})
+	}
+}
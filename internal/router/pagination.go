@@ -0,0 +1,103 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Pagination describes the page of a list response.
+type Pagination struct {
+	Total      int    `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// writeListResponse writes a page of a list together with the pagination
+// metadata and an RFC 5988 Link header with first, prev, next and last pages.
+// When the request was made with a cursor the links follow nextCursor and
+// there are no prev and last pages.
+func writeListResponse(w http.ResponseWriter, r *http.Request, data any, total, limit, offset int, nextCursor string) {
+	p := &Pagination{
+		Total:      total,
+		Limit:      limit,
+		Offset:     offset,
+		NextCursor: nextCursor,
+	}
+
+	var links []string
+
+	switch {
+	case r.URL.Query().Has("cursor"):
+		p.Offset = 0
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="first"`, cursorURL(r, "")))
+
+		if nextCursor != "" {
+			p.Next = cursorURL(r, nextCursor)
+			links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next))
+		}
+	case limit > 0:
+		last := 0
+		if total > 0 {
+			last = (total - 1) / limit * limit
+		}
+
+		if offset+limit < total {
+			p.Next = pageURL(r, limit, offset+limit)
+		}
+
+		// past the end the previous page is the last one
+		if offset > 0 {
+			p.Prev = pageURL(r, limit, min(max(offset-limit, 0), last))
+		}
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="first"`, pageURL(r, limit, 0)))
+
+		if p.Prev != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, p.Prev))
+		}
+
+		if p.Next != "" {
+			links = append(links, fmt.Sprintf(`<%s>; rel="next"`, p.Next))
+		}
+
+		links = append(links, fmt.Sprintf(`<%s>; rel="last"`, pageURL(r, limit, last)))
+	}
+
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	writeResponse(w, http.StatusOK, HTTPResponse{Data: data, Pagination: p})
+}
+
+// cursorURL is the request URL with the cursor replaced, an empty cursor
+// points to the first page.
+func cursorURL(r *http.Request, cursor string) string {
+	q := r.URL.Query()
+	q.Del("offset")
+	q.Del("cursor")
+
+	if cursor != "" {
+		q.Set("cursor", cursor)
+	}
+
+	return r.URL.Path + "?" + q.Encode()
+}
+
+// pageURL is the request URL with limit and offset replaced.
+func pageURL(r *http.Request, limit, offset int) string {
+	q := r.URL.Query()
+	q.Del("cursor")
+	q.Set("limit", strconv.Itoa(limit))
+	q.Set("offset", strconv.Itoa(offset))
+
+	return r.URL.Path + "?" + q.Encode()
+}
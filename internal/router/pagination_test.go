@@ -0,0 +1,117 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestWriteListResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		total      int
+		limit      int
+		offset     int
+		nextCursor string
+		link       string
+		want       Pagination
+	}{
+		{
+			name:  "first page",
+			url:   "/api/v1/car?mark=lada&limit=10",
+			total: 25, limit: 10, offset: 0,
+			link: `</api/v1/car?limit=10&mark=lada&offset=0>; rel="first", ` +
+				`</api/v1/car?limit=10&mark=lada&offset=10>; rel="next", ` +
+				`</api/v1/car?limit=10&mark=lada&offset=20>; rel="last"`,
+			want: Pagination{Total: 25, Limit: 10, Next: "/api/v1/car?limit=10&mark=lada&offset=10"},
+		},
+		{
+			name:  "middle page",
+			url:   "/api/v1/car?limit=10&offset=15",
+			total: 40, limit: 10, offset: 15,
+			link: `</api/v1/car?limit=10&offset=0>; rel="first", ` +
+				`</api/v1/car?limit=10&offset=5>; rel="prev", ` +
+				`</api/v1/car?limit=10&offset=25>; rel="next", ` +
+				`</api/v1/car?limit=10&offset=30>; rel="last"`,
+			want: Pagination{Total: 40, Limit: 10, Offset: 15, Next: "/api/v1/car?limit=10&offset=25", Prev: "/api/v1/car?limit=10&offset=5"},
+		},
+		{
+			name:  "last page",
+			url:   "/api/v1/car?limit=10&offset=20",
+			total: 25, limit: 10, offset: 20,
+			link: `</api/v1/car?limit=10&offset=0>; rel="first", ` +
+				`</api/v1/car?limit=10&offset=10>; rel="prev", ` +
+				`</api/v1/car?limit=10&offset=20>; rel="last"`,
+			want: Pagination{Total: 25, Limit: 10, Offset: 20, Prev: "/api/v1/car?limit=10&offset=10"},
+		},
+		{
+			name:  "offset past total",
+			url:   "/api/v1/car?limit=10&offset=50",
+			total: 25, limit: 10, offset: 50,
+			link: `</api/v1/car?limit=10&offset=0>; rel="first", ` +
+				`</api/v1/car?limit=10&offset=20>; rel="prev", ` +
+				`</api/v1/car?limit=10&offset=20>; rel="last"`,
+			want: Pagination{Total: 25, Limit: 10, Offset: 50, Prev: "/api/v1/car?limit=10&offset=20"},
+		},
+		{
+			name:  "empty list",
+			url:   "/api/v1/car?limit=10",
+			total: 0, limit: 10, offset: 0,
+			link: `</api/v1/car?limit=10&offset=0>; rel="first", ` +
+				`</api/v1/car?limit=10&offset=0>; rel="last"`,
+			want: Pagination{Limit: 10},
+		},
+		{
+			name:  "no limit",
+			url:   "/api/v1/car",
+			total: 25,
+			want:  Pagination{Total: 25},
+		},
+		{
+			name:  "cursor",
+			url:   "/api/v1/car?sort=year&limit=10&offset=5&cursor=abc",
+			total: 25, limit: 10, offset: 5, nextCursor: "def",
+			link: `</api/v1/car?limit=10&sort=year>; rel="first", ` +
+				`</api/v1/car?cursor=def&limit=10&sort=year>; rel="next"`,
+			want: Pagination{Total: 25, Limit: 10, Next: "/api/v1/car?cursor=def&limit=10&sort=year", NextCursor: "def"},
+		},
+		{
+			name:  "cursor of the last page",
+			url:   "/api/v1/car?limit=10&cursor=abc",
+			total: 25, limit: 10,
+			link: `</api/v1/car?limit=10>; rel="first"`,
+			want: Pagination{Total: 25, Limit: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			writeListResponse(w, r, []int{}, tt.total, tt.limit, tt.offset, tt.nextCursor)
+
+			if got := w.Header().Get("Link"); got != tt.link {
+				t.Errorf("Link = %s\nwant   %s", got, tt.link)
+			}
+
+			if got := w.Header().Get("X-Total-Count"); got != strconv.Itoa(tt.total) {
+				t.Errorf("X-Total-Count = %s, want %d", got, tt.total)
+			}
+
+			var res struct {
+				Pagination Pagination `json:"pagination"`
+			}
+
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatal(err)
+			}
+
+			if res.Pagination != tt.want {
+				t.Errorf("pagination = %+v\nwant         %+v", res.Pagination, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/regnum"
+	"github.com/basedalex/effective-mobile-test/internal/validate"
+)
+
+// Content types of PATCH /api/v1/car/{id}, plain application/json keeps the
+// old semantics where empty values are left untouched.
+const (
+	contentTypeMergePatch = "application/merge-patch+json"
+	contentTypeJSONPatch  = "application/json-patch+json"
+)
+
+// mergePatch holds the fields of updatePayload present in a request, a json
+// null clears the field.
+type mergePatch map[string]json.RawMessage
+
+// patchFields are the fields a patch may hold and whether they can be cleared.
+var patchFields = map[string]bool{
+	"regNum":     false,
+	"mark":       false,
+	"model":      false,
+	"year":       true,
+	"name":       false,
+	"surname":    false,
+	"patronymic": true,
+	"version":    false,
+}
+
+// readPatch decodes the PATCH body according to its content type. A JSON Patch
+// is applied to the current car and turned into the merge patch of the fields
+// it changed, which is based on the version it was applied to. strict is false
+// for plain JSON, which can't tell absent fields from empty ones.
+func (h *Handler) readPatch(r *http.Request, id int) (patch mergePatch, strict bool, err error) {
+	contentType := r.Header.Get("Content-Type")
+	if contentType != "" {
+		contentType, _, err = mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, false, errs.Validation("invalid Content-Type")
+		}
+	}
+
+	switch contentType {
+	case "", "application/json":
+		var payload updatePayload
+
+		err = json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			return nil, false, errs.Validation("invalid request body: " + err.Error())
+		}
+
+		patch, err = payload.mergePatch()
+
+		return patch, false, err
+	case contentTypeMergePatch:
+		err = json.NewDecoder(r.Body).Decode(&patch)
+		if err != nil {
+			return nil, true, errs.Validation("invalid request body, expected a JSON object: " + err.Error())
+		}
+
+		return patch, true, nil
+	case contentTypeJSONPatch:
+		var ops []patchOp
+
+		err = json.NewDecoder(r.Body).Decode(&ops)
+		if err != nil {
+			return nil, true, errs.Validation("invalid request body, expected an array of operations: " + err.Error())
+		}
+
+		car, err := h.service.GetCarByID(r.Context(), id)
+		if err != nil {
+			return nil, true, err
+		}
+
+		if car.DeletedAt != nil {
+			return nil, true, errs.NotFound("car not found")
+		}
+
+		patch, err = applyJSONPatch(car, ops)
+
+		return patch, true, err
+	default:
+		return nil, false, errs.Validation(fmt.Sprintf("unsupported Content-Type %q, expected application/json, %s or %s", contentType, contentTypeMergePatch, contentTypeJSONPatch))
+	}
+}
+
+// mergePatch keeps the non-empty fields of the payload.
+func (p updatePayload) mergePatch() (mergePatch, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var all mergePatch
+
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(mergePatch, len(all))
+
+	for k, v := range all {
+		if s := string(v); s != `""` && s != "0" {
+			patch[k] = v
+		}
+	}
+
+	return patch, nil
+}
+
+// carPatch validates the merge patch and converts it into a db.CarPatch
+// together with the version it is based on.
+func carPatch(id int, patch mergePatch) (db.CarPatch, int, error) {
+	var (
+		fields  []errs.FieldError
+		payload updatePayload
+		res     = db.CarPatch{ID: id}
+		values  = make(mergePatch, len(patch))
+	)
+
+	for key, raw := range patch {
+		nullable, ok := patchFields[key]
+
+		switch {
+		case !ok:
+			fields = append(fields, errs.FieldError{Field: key, Message: "is not a field of the car"})
+		case string(raw) != "null":
+			values[key] = raw
+		case !nullable:
+			fields = append(fields, errs.FieldError{Field: key, Message: "can't be cleared"})
+		case key == "year":
+			res.Year = new(int)
+		case key == "patronymic":
+			res.Patronymic = new(string)
+		}
+	}
+
+	// the values go through updatePayload to share its validation
+	data, err := json.Marshal(values)
+	if err != nil {
+		return db.CarPatch{}, 0, err
+	}
+
+	err = json.Unmarshal(data, &payload)
+	if err != nil {
+		return db.CarPatch{}, 0, errs.Validation("invalid request body: " + err.Error())
+	}
+
+	fields = append(fields, validate.Struct(&payload)...)
+
+	// the tags skip zero values, which are present here
+	for key := range values {
+		switch key {
+		case "regNum", "mark", "model", "name", "surname":
+			var s string
+			_ = json.Unmarshal(values[key], &s)
+
+			if s == "" {
+				fields = append(fields, errs.FieldError{Field: key, Message: "must not be blank"})
+			}
+		case "year":
+			if payload.Year == 0 {
+				fields = append(fields, errs.FieldError{Field: key, Message: "must be a year, null clears it"})
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+
+		return db.CarPatch{}, 0, errs.InvalidFields(fields)
+	}
+
+	if _, ok := values["regNum"]; ok {
+		res.RegNum = ptr(regnum.Normalize(payload.RegNum))
+	}
+	if _, ok := values["mark"]; ok {
+		res.Mark = ptr(strings.ToLower(payload.Mark))
+	}
+	if _, ok := values["model"]; ok {
+		res.Model = ptr(strings.ToLower(payload.Model))
+	}
+	if _, ok := values["year"]; ok {
+		res.Year = ptr(payload.Year)
+	}
+	if _, ok := values["name"]; ok {
+		res.Name = ptr(strings.ToLower(payload.Name))
+	}
+	if _, ok := values["surname"]; ok {
+		res.Surname = ptr(strings.ToLower(payload.Surname))
+	}
+	if _, ok := values["patronymic"]; ok {
+		res.Patronymic = ptr(strings.ToLower(payload.Patronymic))
+	}
+
+	return res, payload.Version, nil
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// patchOp is an RFC 6902 operation.
+type patchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// applyJSONPatch applies the operations to the car's fields and returns a
+// merge patch of the fields which changed, based on the car's version. The
+// document is flat, so paths are /regNum, /year, /patronymic and so on.
+func applyJSONPatch(car db.Car, ops []patchOp) (mergePatch, error) {
+	doc, err := patchDocument(car)
+	if err != nil {
+		return nil, err
+	}
+
+	orig := make(mergePatch, len(doc))
+	for k, v := range doc {
+		orig[k] = v
+	}
+
+	for i, op := range ops {
+		key, err := patchKey(op.Path)
+		if err != nil {
+			return nil, errs.Validation(fmt.Sprintf("operation %d: %s", i, err))
+		}
+
+		switch op.Op {
+		case "add", "replace":
+			if op.Value == nil {
+				return nil, errs.Validation(fmt.Sprintf("operation %d: value is required", i))
+			}
+
+			doc[key] = op.Value
+		case "remove":
+			doc[key] = json.RawMessage("null")
+		case "copy", "move":
+			from, err := patchKey(op.From)
+			if err != nil {
+				return nil, errs.Validation(fmt.Sprintf("operation %d: from %s", i, err))
+			}
+
+			doc[key] = doc[from]
+
+			if op.Op == "move" && from != key {
+				doc[from] = json.RawMessage("null")
+			}
+		case "test":
+			if op.Value == nil {
+				return nil, errs.Validation(fmt.Sprintf("operation %d: value is required", i))
+			}
+
+			if !jsonEqual(doc[key], op.Value) {
+				return nil, errs.Conflict(fmt.Sprintf("operation %d: test of %s failed", i, op.Path))
+			}
+		default:
+			return nil, errs.Validation(fmt.Sprintf("operation %d: unknown op %q", i, op.Op))
+		}
+	}
+
+	patch := mergePatch{"version": json.RawMessage(fmt.Sprint(car.Version))}
+
+	for k, v := range doc {
+		if !jsonEqual(orig[k], v) {
+			patch[k] = v
+		}
+	}
+
+	return patch, nil
+}
+
+// patchDocument is the car as a merge patch of all its fields, cleared
+// fields are null.
+func patchDocument(car db.Car) (mergePatch, error) {
+	fields := map[string]any{
+		"regNum":     car.RegNum,
+		"mark":       car.Mark,
+		"model":      car.Model,
+		"year":       nil,
+		"name":       car.Owner.Name,
+		"surname":    car.Owner.Surname,
+		"patronymic": nil,
+	}
+
+	if car.Year != 0 {
+		fields["year"] = car.Year
+	}
+	if car.Owner.Patronymic != "" {
+		fields["patronymic"] = car.Owner.Patronymic
+	}
+
+	doc := make(mergePatch, len(fields))
+
+	for k, v := range fields {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+
+		doc[k] = data
+	}
+
+	return doc, nil
+}
+
+func patchKey(path string) (string, error) {
+	key, ok := strings.CutPrefix(path, "/")
+	if _, known := patchFields[key]; !ok || !known || key == "version" {
+		return "", fmt.Errorf("unknown path %q", path)
+	}
+
+	return key, nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var x, y any
+
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return bytes.Equal(a, b)
+	}
+
+	return reflect.DeepEqual(x, y)
+}
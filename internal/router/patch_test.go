@@ -0,0 +1,249 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+func testCar() db.Car {
+	return db.Car{
+		ID:      7,
+		RegNum:  "А123ВС77",
+		Mark:    "lada",
+		Model:   "vesta",
+		Year:    2020,
+		Version: 3,
+		Owner: db.People{
+			Name:       "иван",
+			Surname:    "иванов",
+			Patronymic: "иванович",
+		},
+	}
+}
+
+// decodePatch turns a merge patch into plain values for comparison.
+func decodePatch(t *testing.T, patch mergePatch) map[string]any {
+	t.Helper()
+
+	res := make(map[string]any, len(patch))
+
+	for k, v := range patch {
+		var x any
+		if err := json.Unmarshal(v, &x); err != nil {
+			t.Fatalf("%s: %s", k, err)
+		}
+
+		res[k] = x
+	}
+
+	return res
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     string
+		want    map[string]any
+		wantErr error
+	}{
+		{
+			name: "replace",
+			ops:  `[{"op": "replace", "path": "/mark", "value": "kia"}]`,
+			want: map[string]any{"mark": "kia", "version": 3.0},
+		},
+		{
+			name: "remove sets null",
+			ops:  `[{"op": "remove", "path": "/year"}]`,
+			want: map[string]any{"year": nil, "version": 3.0},
+		},
+		{
+			name: "move sets the source to null",
+			ops:  `[{"op": "move", "from": "/patronymic", "path": "/surname"}]`,
+			want: map[string]any{"surname": "иванович", "patronymic": nil, "version": 3.0},
+		},
+		{
+			name: "copy keeps the source",
+			ops:  `[{"op": "copy", "from": "/mark", "path": "/model"}]`,
+			want: map[string]any{"model": "lada", "version": 3.0},
+		},
+		{
+			name: "passed test",
+			ops:  `[{"op": "test", "path": "/year", "value": 2020}, {"op": "replace", "path": "/year", "value": 2021}]`,
+			want: map[string]any{"year": 2021.0, "version": 3.0},
+		},
+		{
+			name: "no changes keep only the version",
+			ops:  `[{"op": "replace", "path": "/mark", "value": "lada"}]`,
+			want: map[string]any{"version": 3.0},
+		},
+		{
+			name:    "failed test",
+			ops:     `[{"op": "test", "path": "/mark", "value": "kia"}]`,
+			wantErr: errs.ErrConflict,
+		},
+		{
+			name:    "test without value",
+			ops:     `[{"op": "test", "path": "/mark"}]`,
+			wantErr: errs.ErrValidation,
+		},
+		{
+			name:    "replace without value",
+			ops:     `[{"op": "replace", "path": "/mark"}]`,
+			wantErr: errs.ErrValidation,
+		},
+		{
+			name:    "unknown path",
+			ops:     `[{"op": "replace", "path": "/color", "value": "red"}]`,
+			wantErr: errs.ErrValidation,
+		},
+		{
+			name:    "version can't be patched",
+			ops:     `[{"op": "replace", "path": "/version", "value": 1}]`,
+			wantErr: errs.ErrValidation,
+		},
+		{
+			name:    "unknown op",
+			ops:     `[{"op": "merge", "path": "/mark", "value": "kia"}]`,
+			wantErr: errs.ErrValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ops []patchOp
+			if err := json.Unmarshal([]byte(tt.ops), &ops); err != nil {
+				t.Fatal(err)
+			}
+
+			patch, err := applyJSONPatch(testCar(), ops)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := decodePatch(t, patch); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("patch = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCarPatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		patch       string
+		want        db.CarPatch
+		wantVersion int
+		wantFields  []errs.FieldError
+	}{
+		{
+			name:  "values are normalized",
+			patch: `{"regNum": "x123xx150", "mark": "Kia", "name": "Пётр"}`,
+			want:  db.CarPatch{ID: 7, RegNum: ptr("Х123ХХ150"), Mark: ptr("kia"), Name: ptr("пётр")},
+		},
+		{
+			name:  "null clears year and patronymic",
+			patch: `{"year": null, "patronymic": null}`,
+			want:  db.CarPatch{ID: 7, Year: ptr(0), Patronymic: ptr("")},
+		},
+		{
+			name:        "version is returned",
+			patch:       `{"mark": "kia", "version": 3}`,
+			want:        db.CarPatch{ID: 7, Mark: ptr("kia")},
+			wantVersion: 3,
+		},
+		{
+			name:       "null for a field which can't be cleared",
+			patch:      `{"name": null, "mark": null}`,
+			wantFields: []errs.FieldError{{Field: "mark", Message: "can't be cleared"}, {Field: "name", Message: "can't be cleared"}},
+		},
+		{
+			name:       "blank and zero values",
+			patch:      `{"mark": "", "surname": " ", "year": 0}`,
+			wantFields: []errs.FieldError{{Field: "mark", Message: "must not be blank"}, {Field: "surname", Message: "must not be blank"}, {Field: "year", Message: "must be a year, null clears it"}},
+		},
+		{
+			name:       "unknown field",
+			patch:      `{"color": "red"}`,
+			wantFields: []errs.FieldError{{Field: "color", Message: "is not a field of the car"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch mergePatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+
+			got, version, err := carPatch(7, patch)
+			if tt.wantFields != nil {
+				if !errors.Is(err, errs.ErrValidation) {
+					t.Fatalf("err = %v, want a validation error", err)
+				}
+
+				if fields := errs.Fields(err); !reflect.DeepEqual(fields, tt.wantFields) {
+					t.Fatalf("fields = %v, want %v", fields, tt.wantFields)
+				}
+
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("patch = %+v, want %+v", got, tt.want)
+			}
+			if version != tt.wantVersion {
+				t.Fatalf("version = %d, want %d", version, tt.wantVersion)
+			}
+		})
+	}
+}
+
+func TestJSONPatchVersion(t *testing.T) {
+	ops := []patchOp{{Op: "remove", Path: "/year"}}
+
+	patch, err := applyJSONPatch(testCar(), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update, version, err := carPatch(7, patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version != 3 {
+		t.Fatalf("version = %d, want the car's version 3", version)
+	}
+	if update.Year == nil || *update.Year != 0 {
+		t.Fatalf("year = %v, want cleared", update.Year)
+	}
+}
+
+func TestUpdatePayloadMergePatch(t *testing.T) {
+	p := updatePayload{Mark: "Kia", Model: "", Year: 0, Name: "Пётр"}
+
+	patch, err := p.mergePatch()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{"mark": "Kia", "name": "Пётр"}
+	if got := decodePatch(t, patch); !reflect.DeepEqual(got, want) {
+		t.Fatalf("patch = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/go-chi/chi/v5"
+	log "github.com/sirupsen/logrus"
+)
+
+// Stable error codes returned in HTTPResponse.Code.
+const (
+	codeNotFound            = "not_found"
+	codeConflict            = "conflict"
+	codeValidation          = "validation_error"
+	codeUpstreamUnavailable = "upstream_unavailable"
+	codePreconditionFailed  = "precondition_failed"
+	codeInternal            = "internal_error"
+)
+
+type HTTPResponse struct {
+	Data       any         `json:"data,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Code       string      `json:"code,omitempty"`
+	// Fields lists every invalid field of a validation error
+	Fields []errs.FieldError `json:"fields,omitempty"`
+}
+
+func writeOkResponse(w http.ResponseWriter, statusCode int, data any) {
+	if data == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(statusCode)
+
+		return
+	}
+
+	writeResponse(w, statusCode, HTTPResponse{Data: data})
+}
+
+func writeResponse(w http.ResponseWriter, statusCode int, res HTTPResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	err := json.NewEncoder(w).Encode(res)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// writeErrResponse maps err to an http status and error code. Only messages of
+// domain errors reach the client, anything else is reported as internal.
+func writeErrResponse(w http.ResponseWriter, err error) {
+	statusCode, code := errorStatus(err)
+
+	if statusCode >= http.StatusInternalServerError {
+		log.Error(err)
+	} else {
+		log.Warn(err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	jsonErr := json.NewEncoder(w).Encode(HTTPResponse{Error: clientMessage(err), Code: code, Fields: errs.Fields(err)})
+	if jsonErr != nil {
+		log.Error(jsonErr)
+	}
+}
+
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound, codeNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict, codeConflict
+	case errors.Is(err, errs.ErrValidation):
+		return http.StatusBadRequest, codeValidation
+	case errors.Is(err, errs.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed, codePreconditionFailed
+	case errors.Is(err, errs.ErrUpstreamUnavailable):
+		return http.StatusBadGateway, codeUpstreamUnavailable
+	default:
+		return http.StatusInternalServerError, codeInternal
+	}
+}
+
+func clientMessage(err error) string {
+	if msg, ok := errs.Message(err); ok {
+		return msg
+	}
+
+	return "internal server error"
+}
+
+func carID(r *http.Request) (int, error) {
+	return pathID(r, "car id must be a positive integer")
+}
+
+func ownerID(r *http.Request) (int, error) {
+	return pathID(r, "owner id must be a positive integer")
+}
+
+func pathID(r *http.Request, msg string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return 0, errs.Validation(msg)
+	}
+
+	return id, nil
+}
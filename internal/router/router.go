@@ -6,42 +6,72 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/basedalex/effective-mobile-test/docs"
 	"github.com/basedalex/effective-mobile-test/internal/api"
 	"github.com/basedalex/effective-mobile-test/internal/config"
 	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/regnum"
 	"github.com/basedalex/effective-mobile-test/internal/types"
+	"github.com/basedalex/effective-mobile-test/internal/validate"
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	log "github.com/sirupsen/logrus"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-type HTTPResponse struct {
-	Data  any    `json:"data,omitempty"`
-	Error string `json:"error,omitempty"`
-}
-
 type carService interface {
-	CreateCar(ctx context.Context, c db.Car) error
+	CreateCar(ctx context.Context, c db.Car, onConflict db.ConflictPolicy) (id int, inserted bool, err error)
 	GetCar(ctx context.Context, c *types.GetCarQuery) ([]*db.Car, error)
-	UpdateCar(ctx context.Context, c db.Car) (db.Car, error)
+	CountCars(ctx context.Context, c *types.GetCarQuery) (int, error)
+	GetCarByID(ctx context.Context, id int) (db.Car, error)
+	GetCarByRegNum(ctx context.Context, regNum string) (db.Car, error)
+	UpdateCar(ctx context.Context, p db.CarPatch) (db.Car, error)
 	DeleteCar(ctx context.Context, id int) error
+	RestoreCar(ctx context.Context, id int) (db.Car, error)
+	TransferCar(ctx context.Context, t db.Transfer) (db.Car, error)
+	GetOwnership(ctx context.Context, carID int, at time.Time) ([]*db.Ownership, error)
+}
+
+type ownerService interface {
+	GetOwners(ctx context.Context, q *types.GetOwnerQuery) ([]*db.People, error)
+	CountOwners(ctx context.Context, q *types.GetOwnerQuery) (int, error)
+	GetOwnerByID(ctx context.Context, id int) (db.People, error)
+	CreateOwner(ctx context.Context, p db.People) (db.People, error)
+	UpdateOwner(ctx context.Context, p db.People) (db.People, error)
+	MergeOwners(ctx context.Context, targetID int, sourceIDs []int) (db.People, error)
+	DeleteOwner(ctx context.Context, id int) error
+}
+
+type auditService interface {
+	GetAuditEvents(ctx context.Context, q *types.GetAuditQuery) ([]*db.AuditEvent, error)
+	CountAuditEvents(ctx context.Context, q *types.GetAuditQuery) (int, error)
 }
 
 type Handler struct {
-	service carService
-	apiClient api.Client
+	service   carService
+	owners    ownerService
+	audit     auditService
+	apiClient *api.Client
+
+	// putCreate lets PUT by regNum create cars which aren't in the catalogue
+	putCreate bool
+
+	// enrichment limits for bulk car creation
+	enrichWorkers      int
+	enrichCallTimeout  time.Duration
+	enrichTotalTimeout time.Duration
 }
 
-func NewServer(ctx context.Context, cfg *config.Config, service carService, apiClient api.Client) error {
+func NewServer(ctx context.Context, cfg *config.Config, service carService, owners ownerService, audit auditService, apiClient *api.Client) error {
 	srv := &http.Server{
 		Addr:              ":" + cfg.Env.Port,
-		Handler:           newRouter(service, apiClient),
+		Handler:           newRouter(cfg, service, owners, audit, apiClient),
 		ReadHeaderTimeout: 3 * time.Second,
 	}
 
@@ -64,59 +94,150 @@ func NewServer(ctx context.Context, cfg *config.Config, service carService, apiC
 	return nil
 }
 
-func newRouter(service carService, apiClient api.Client) *chi.Mux {
+func newRouter(cfg *config.Config, service carService, owners ownerService, audit auditService, apiClient *api.Client) *chi.Mux {
 	handler := &Handler{
-		service: service,
-		apiClient: apiClient,
+		service:            service,
+		owners:             owners,
+		audit:              audit,
+		apiClient:          apiClient,
+		putCreate:          cfg.Env.PutCreate,
+		enrichWorkers:      max(cfg.Env.EnrichWorkers, 1),
+		enrichCallTimeout:  time.Duration(cfg.Env.EnrichCallTimeout) * time.Second,
+		enrichTotalTimeout: time.Duration(cfg.Env.EnrichTotalTimeout) * time.Second,
 	}
 
 	r := chi.NewRouter()
 
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins: []string{"https://*", "http://*"},
-		AllowedMethods: []string{"GET", "POST", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders: []string{"Link"},
+		AllowedOrigins:   []string{"https://*", "http://*"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Cache-Control", "Content-Type", "If-Match", "If-Modified-Since", "If-None-Match", "X-Actor", "X-CSRF-Token", "X-Request-Id"},
+		ExposedHeaders:   []string{"ETag", "Last-Modified", "Link", "X-Request-Id", "X-Total-Count"},
 		AllowCredentials: true,
-		MaxAge: 300,
+		MaxAge:           300,
 	}))
+	r.Use(middleware.RequestID, auditActor)
 
 	r.Get("/swagger/*", httpSwagger.Handler(
 		httpSwagger.URL("http://localhost:8181/swagger/doc.json"),
 	))
 
+	r.Get("/api/v1/health", handler.health)
+
 	r.Post("/api/v1/car", handler.createCar)
 	r.Get("/api/v1/car", handler.getCar)
+	r.Get("/api/v1/car/{id}", handler.getCarByID)
+	r.Put("/api/v1/car/{id}", handler.replaceCar)
+	r.Put("/api/v1/car/by-reg-num/{regNum}", handler.replaceCarByRegNum)
 	r.Patch("/api/v1/car/{id}", handler.updateCar)
 	r.Delete("/api/v1/car/{id}", handler.deleteCar)
+	r.Post("/api/v1/car/{id}/restore", handler.restoreCar)
+	r.Post("/api/v1/car/{id}/transfer", handler.transferCar)
+	r.Get("/api/v1/car/{id}/owners", handler.getCarOwners)
+	r.Get("/api/v1/car/{id}/history", handler.getCarHistory)
+
+	r.Get("/api/v1/owners", handler.getOwners)
+	r.Post("/api/v1/owners", handler.createOwner)
+	r.Get("/api/v1/owners/{id}", handler.getOwnerByID)
+	r.Patch("/api/v1/owners/{id}", handler.updateOwner)
+	r.Delete("/api/v1/owners/{id}", handler.deleteOwner)
+	r.Post("/api/v1/owners/{id}/merge", handler.mergeOwners)
+	r.Get("/api/v1/owners/{id}/cars", handler.getOwnerCars)
+
+	r.Get("/api/v1/audit", handler.getAudit)
+
+	r.Delete("/api/v1/info-cache/{regNum}", handler.invalidateInfoCache)
 
 	return r
 }
 
+type healthResponse struct {
+	InfoAPI api.BreakerStats `json:"infoApi"`
+}
+
+// @Summary Health
+// @Tags health
+// @Description service health and info API circuit breaker state
+// @ID health
+// @Produce json
+// @Success 200 {object} HTTPResponse{data=healthResponse}
+// @Router /api/v1/health [get]
+func (h *Handler) health(w http.ResponseWriter, r *http.Request) {
+	writeOkResponse(w, http.StatusOK, healthResponse{InfoAPI: h.apiClient.BreakerStats()})
+}
+
 type payload struct {
-	RegNums []string `json:"regNums"`
+	RegNums []string `json:"regNums" validate:"required,max=1000,dive,regnum"`
+	// OnConflict is what to do with regNums which are already in the catalogue:
+	// error (default), skip or overwrite
+	OnConflict db.ConflictPolicy `json:"onConflict,omitempty" enums:"error,skip,overwrite" validate:"oneof=error skip overwrite"`
 }
 
 type updatePayload struct {
-	RegNum string `json:"regNum"`
-	Mark string `json:"mark"`
-	Model string `json:"model"`
-	Year int `json:"year"`
-	Name string `json:"name"`
-	Surname string `json:"surname"`
-	Patronymic string `json:"patronymic"`
+	RegNum     string `json:"regNum" validate:"regnum"`
+	Mark       string `json:"mark" validate:"notblank,max=100"`
+	Model      string `json:"model" validate:"notblank,max=100"`
+	Year       int    `json:"year" validate:"year"`
+	Name       string `json:"name" validate:"notblank,max=100"`
+	Surname    string `json:"surname" validate:"notblank,max=100"`
+	Patronymic string `json:"patronymic" validate:"max=100"`
+	// Version the change is based on, the same as sending its ETag in If-Match
+	Version int `json:"version,omitempty" validate:"min=1"`
+}
+
+// replacePayload is the whole car of a PUT, the fields api.yaml requires must
+// be there and absent optional ones are cleared.
+type replacePayload struct {
+	RegNum string `json:"regNum" validate:"required,regnum"`
+	Mark   string `json:"mark" validate:"required,max=100"`
+	Model  string `json:"model" validate:"required,max=100"`
+	Year   int    `json:"year,omitempty" validate:"year"`
+	Owner  struct {
+		Name       string `json:"name" validate:"required,max=100"`
+		Surname    string `json:"surname" validate:"required,max=100"`
+		Patronymic string `json:"patronymic,omitempty" validate:"max=100"`
+	} `json:"owner"`
+	// Version the replacement is based on, the same as sending its ETag in If-Match
+	Version int `json:"version,omitempty" validate:"min=1"`
+}
+
+// createStatus is the outcome of creating a single car from a regNum.
+type createStatus string
+
+const (
+	statusCreated          createStatus = "created"
+	statusUpdated          createStatus = "updated"
+	statusSkipped          createStatus = "skipped"
+	statusEnrichmentFailed createStatus = "enrichment_failed"
+	statusDuplicate        createStatus = "duplicate"
+	statusDBError          createStatus = "db_error"
+)
+
+// ok reports whether the regNum ended up in the catalogue.
+func (s createStatus) ok() bool {
+	return s == statusCreated || s == statusUpdated || s == statusSkipped
+}
+
+type createResult struct {
+	RegNum         string       `json:"regNum"`
+	Status         createStatus `json:"status"`
+	CarID          int          `json:"carId,omitempty"`
+	UpstreamStatus int          `json:"upstreamStatus,omitempty"`
+	Error          string       `json:"error,omitempty"`
 }
 
 // @Summary CreateCar
 // @Tags car
-// @Description create car
+// @Description create cars, the response lists the outcome for every regNum
 // @ID create-car
 // @Accept json
 // @Produce json
 // @Param request body payload true "regnum array to create cars in car catalogue API"
-// @Success 201 {integer} integer 1
-// @Failure 400 {object} HTTPResponse 
-// @Failure 500 {object} HTTPResponse
+// @Param Cache-Control header string false "no-cache to skip cached info API answers"
+// @Success 201 {object} HTTPResponse{data=[]createResult} "all cars stored"
+// @Success 207 {object} HTTPResponse{data=[]createResult} "some cars stored"
+// @Failure 400 {object} HTTPResponse
+// @Failure 422 {object} HTTPResponse{data=[]createResult} "no cars stored"
 // @Failure default {object} HTTPResponse
 // @Router /api/v1/car [post]
 func (h *Handler) createCar(w http.ResponseWriter, r *http.Request) {
@@ -124,81 +245,208 @@ func (h *Handler) createCar(w http.ResponseWriter, r *http.Request) {
 
 	err := json.NewDecoder(r.Body).Decode(&cars)
 	if err != nil {
-		log.Warn(err)
-		writeErrResponse(w, http.StatusBadRequest, err)
+		writeErrResponse(w, errs.Validation("invalid request body: "+err.Error()))
 		return
 	}
 
-	counter := 0
+	if err = validPayload(&cars); err != nil {
+		writeErrResponse(w, err)
+		return
+	}
 
-	for _, v := range cars.RegNums {
-		car, err := h.apiClient.GetInfo(r.Context(), v)
-		if err != nil {
-			log.Warnf("%s: %s", v, err)
+	if cars.OnConflict == "" {
+		cars.OnConflict = db.ConflictError
+	}
 
+	ctx, cancel := context.WithTimeout(r.Context(), h.enrichTotalTimeout)
+	defer cancel()
+
+	if strings.Contains(r.Header.Get("Cache-Control"), "no-cache") {
+		ctx = api.BypassCache(ctx)
+	}
+
+	results := make([]createResult, len(cars.RegNums))
+	// indexes of the regNums which have to be enriched, repeated ones are reported right away
+	jobs := make(chan int, len(cars.RegNums))
+	seen := make(map[string]bool, len(cars.RegNums))
+
+	for i, v := range cars.RegNums {
+		cars.RegNums[i] = regnum.Normalize(v)
+
+		if seen[cars.RegNums[i]] {
+			results[i] = createResult{RegNum: cars.RegNums[i], Status: statusDuplicate, Error: "regNum repeated in request"}
 			continue
 		}
-		dbCar := db.Car{
-			RegNum: car.RegNum,
-			Mark: strings.ToLower(car.Mark),
-			Model: strings.ToLower(car.Model),
-			Year: car.Year,
-			Owner: db.People{
-				Name: strings.ToLower(car.Owner.Name),
-				Surname: strings.ToLower(car.Owner.Surname),
-				Patronymic: strings.ToLower(car.Owner.Patronymic),
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		err = h.service.CreateCar(r.Context(), dbCar)
-		if err != nil {
-			log.Warnf("%s: %s", v, err)
+		seen[cars.RegNums[i]] = true
 
-			continue
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+
+	for range min(h.enrichWorkers, len(cars.RegNums)) {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				results[i] = h.createOne(ctx, cars.RegNums[i], cars.OnConflict)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	counter := 0
+
+	for _, res := range results {
+		if res.Status.ok() {
+			counter++
 		}
-		counter++
 	}
 
-	if counter > 0 {
-		writeOkResponse(w, http.StatusCreated, nil)
-	} else {
-		writeErrResponse(w, http.StatusInternalServerError, err)
+	switch {
+	case counter == len(results):
+		writeOkResponse(w, http.StatusCreated, results)
+	case counter > 0:
+		writeOkResponse(w, http.StatusMultiStatus, results)
+	default:
+		writeOkResponse(w, http.StatusUnprocessableEntity, results)
 	}
 }
 
-func getQuery(r *http.Request) types.GetCarQuery {
-	yearStr := r.URL.Query().Get("year")
-	year, err := strconv.Atoi(yearStr)
-	if err != nil {
-		log.Warn(err)
-		year = 0
+// createOne enriches a single regNum through the info API and stores it.
+func (h *Handler) createOne(ctx context.Context, regNum string, onConflict db.ConflictPolicy) createResult {
+	res := createResult{RegNum: regNum}
+
+	if err := ctx.Err(); err != nil {
+		res.Status = statusEnrichmentFailed
+		res.Error = "request deadline exceeded before enrichment"
+
+		return res
 	}
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
 
-	limit, err := strconv.Atoi(limitStr)
+	infoCtx, cancel := context.WithTimeout(ctx, h.enrichCallTimeout)
+	defer cancel()
+
+	// regNum is canonical, the info service knows plates in Latin
+	car, err := h.apiClient.GetInfo(infoCtx, regnum.Latin(regNum))
 	if err != nil {
-		log.Warn(err)
-		limit = 0
+		log.Warnf("%s: %s", regNum, err)
+
+		res.Status = statusEnrichmentFailed
+		res.Error = clientMessage(err)
+
+		var statusErr *api.StatusError
+
+		switch {
+		case errors.As(err, &statusErr):
+			res.UpstreamStatus = statusErr.StatusCode
+			res.Error = statusErr.Error()
+		case errors.Is(err, context.DeadlineExceeded):
+			res.Error = "info api request timed out"
+		}
+
+		return res
+	}
+
+	dbCar := db.Car{
+		RegNum: regNum,
+		Mark:   strings.ToLower(car.Mark),
+		Model:  strings.ToLower(car.Model),
+		Year:   car.Year,
+		Owner: db.People{
+			Name:       strings.ToLower(car.Owner.Name),
+			Surname:    strings.ToLower(car.Owner.Surname),
+			Patronymic: strings.ToLower(car.Owner.Patronymic),
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	id, inserted, err := h.service.CreateCar(ctx, dbCar, onConflict)
+	if errors.Is(err, errs.ErrConflict) {
+		res.Status = statusDuplicate
+		res.CarID = id
+		res.Error = clientMessage(err)
+
+		return res
 	}
-	offset, err := strconv.Atoi(offsetStr)
 	if err != nil {
-		log.Warn(err)
-		offset = 0
+		log.Warnf("%s: %s", regNum, err)
+
+		res.Status = statusDBError
+		res.Error = "failed to save car"
+
+		return res
 	}
 
-	return types.GetCarQuery{
-		RegNum: r.URL.Query().Get("regNum"),
-		Mark: strings.ToLower(r.URL.Query().Get("mark")),
-		Model: strings.ToLower(r.URL.Query().Get("model")),
-		Year: year,
-		Name: strings.ToLower(r.URL.Query().Get("name")),
-		Surname: strings.ToLower(r.URL.Query().Get("surname")),
-		Patronymic: strings.ToLower(r.URL.Query().Get("patronymic")),
-		Limit: limit,
-		Offset: offset,
+	res.CarID = id
+
+	switch {
+	case inserted:
+		res.Status = statusCreated
+	case onConflict == db.ConflictOverwrite:
+		res.Status = statusUpdated
+	default:
+		res.Status = statusSkipped
 	}
+
+	return res
+}
+
+// getQuery binds and validates the car list filters, all invalid parameters
+// are reported together.
+func getQuery(r *http.Request) (types.GetCarQuery, error) {
+	var c types.GetCarQuery
+
+	fields := validate.Query(r.URL.Query(), &c)
+	fields = append(fields, validate.Struct(&c)...)
+
+	if c.YearFrom != 0 && c.YearTo != 0 && c.YearFrom > c.YearTo {
+		fields = append(fields, errs.FieldError{Field: "yearTo", Message: "must not be before yearFrom"})
+	}
+
+	if len(fields) > 0 {
+		return c, errs.InvalidFields(fields)
+	}
+
+	for i, v := range c.RegNum {
+		c.RegNum[i] = regnum.Normalize(v)
+	}
+
+	c.Region = regnum.SubjectCodes(c.Region)
+
+	c.Mark = lowerAll(c.Mark)
+	c.Model = lowerAll(c.Model)
+	c.Name = lowerAll(c.Name)
+	c.Surname = lowerAll(c.Surname)
+	c.Patronymic = lowerAll(c.Patronymic)
+
+	return c, nil
+}
+
+// validPayload checks a decoded request body against its validate tags.
+func validPayload(v any) error {
+	if fields := validate.Struct(v); len(fields) > 0 {
+		return errs.InvalidFields(fields)
+	}
+
+	return nil
+}
+
+func lowerAll(values []string) []string {
+	res := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if v != "" {
+			res = append(res, strings.ToLower(v))
+		}
+	}
+
+	return res
 }
 
 // @Summary GetCar
@@ -207,121 +455,461 @@ func getQuery(r *http.Request) types.GetCarQuery {
 // @ID get-car
 // @Accept json
 // @Produce json
-// @Param q query string false "search options"
-// @Success 200 {intgeger} HTTPResponse
+// @Param regNum query []string false "registration number, repeat for several" collectionFormat(multi)
+// @Param region query []string false "region code, matches all codes of its subject, repeat for several" collectionFormat(multi)
+// @Param mark query []string false "mark, repeat for several" collectionFormat(multi)
+// @Param model query []string false "model, repeat for several" collectionFormat(multi)
+// @Param modelPrefix query string false "case-insensitive model prefix"
+// @Param modelContains query string false "case-insensitive model substring"
+// @Param year query []int false "year, repeat for several" collectionFormat(multi)
+// @Param yearFrom query int false "minimal year"
+// @Param yearTo query int false "maximal year"
+// @Param name query []string false "owner name, repeat for several" collectionFormat(multi)
+// @Param surname query []string false "owner surname, repeat for several" collectionFormat(multi)
+// @Param surnamePrefix query string false "case-insensitive owner surname prefix"
+// @Param surnameContains query string false "case-insensitive owner surname substring"
+// @Param patronymic query []string false "owner patronymic, repeat for several" collectionFormat(multi)
+// @Param ownerId query int false "owner id"
+// @Param createdAfter query string false "RFC 3339 time or YYYY-MM-DD"
+// @Param createdBefore query string false "RFC 3339 time or YYYY-MM-DD"
+// @Param updatedAfter query string false "RFC 3339 time or YYYY-MM-DD"
+// @Param updatedBefore query string false "RFC 3339 time or YYYY-MM-DD"
+// @Param sort query string false "comma separated sort fields, minus for descending, e.g. -year,mark"
+// @Param includeDeleted query bool false "list soft deleted cars too"
+// @Param limit query int false "limit"
+// @Param offset query int false "offset, ignored when cursor is set"
+// @Param cursor query string false "nextCursor of the previous page for keyset pagination"
+// @Success 200 {object} HTTPResponse{data=[]db.Car,pagination=Pagination}
+// @Header 200 {string} Link "RFC 5988 links to the first, prev, next and last pages"
+// @Header 200 {integer} X-Total-Count "number of cars matching the filters"
+// @Failure 400 {object} HTTPResponse
 // @Failure 500 {object} HTTPResponse
 // @Failure default {object} HTTPResponse
 // @Router /api/v1/car [get]
 func (h *Handler) getCar(w http.ResponseWriter, r *http.Request) {
-	payload := getQuery(r)
+	payload, err := getQuery(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
 
 	data, err := h.service.GetCar(r.Context(), &payload)
 	if err != nil {
-		writeErrResponse(w, http.StatusInternalServerError, err)
+		writeErrResponse(w, err)
+		return
+	}
+
+	total, err := h.service.CountCars(r.Context(), &payload)
+	if err != nil {
+		writeErrResponse(w, err)
 		return
 	}
 
-	writeOkResponse(w, http.StatusOK, data)
+	var nextCursor string
+	if payload.Limit > 0 && len(data) == payload.Limit {
+		nextCursor = db.NextCursor(&payload, data[len(data)-1])
+	}
+
+	writeListResponse(w, r, data, total, payload.Limit, payload.Offset, nextCursor)
+}
+
+// @Summary GetCarByID
+// @Tags car
+// @Description get a single car with its owner
+// @ID get-car-by-id
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param includeDeleted query bool false "return the car even if it is soft deleted"
+// @Param If-None-Match header string false "ETag of the cached copy"
+// @Param If-Modified-Since header string false "Last-Modified of the cached copy"
+// @Success 200 {object} HTTPResponse{data=db.Car}
+// @Success 304
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id} [get]
+func (h *Handler) getCarByID(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	var q struct {
+		IncludeDeleted bool `in:"query=includeDeleted"`
+	}
+
+	if fields := validate.Query(r.URL.Query(), &q); len(fields) > 0 {
+		writeErrResponse(w, errs.InvalidFields(fields))
+		return
+	}
+
+	car, err := h.service.GetCarByID(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	if car.DeletedAt != nil && !q.IncludeDeleted {
+		writeErrResponse(w, errs.NotFound("car not found"))
+		return
+	}
+
+	if notModified(w, r, carETag(car), car.UpdatedAt) {
+		return
+	}
+
+	writeOkResponse(w, http.StatusOK, car)
 }
 
 // @Summary UpdateCar
 // @Tags car
-// @Description update car
+// @Description update car. With application/json empty fields are left untouched, with
+// @Description application/merge-patch+json (RFC 7396) absent fields are left untouched and null
+// @Description clears year and patronymic, application/json-patch+json (RFC 6902) operations
+// @Description apply to the flat document of updatePayload fields, e.g. {"op": "remove", "path": "/year"}
 // @ID update-car
-// @Accept json
+// @Accept json,application/merge-patch+json,application/json-patch+json
 // @Produce json
 // @Param id path int true "Car ID"
 // @Param request body updatePayload false "update options"
+// @Param If-Match header string false "ETag the change is based on"
 // @Success 200 {integer} HTTPResponse
 // @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse "regNum is taken or a JSON Patch test operation failed"
+// @Failure 412 {object} HTTPResponse "the car was changed since the given version"
 // @Failure 500 {object} HTTPResponse
 // @Failure default {object} HTTPResponse
 // @Router /api/v1/car/{id} [patch]
 func (h *Handler) updateCar(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	version, err := ifMatchVersion(r, id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	patch, strict, err := h.readPatch(r, id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	update, patchVersion, err := carPatch(id, patch)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	if patchVersion != 0 {
+		if version != 0 && version != patchVersion {
+			writeErrResponse(w, errETagMismatch)
+			return
+		}
+
+		version = patchVersion
+	}
+
+	update.Version = version
+
+	var updatedCar db.Car
+
+	switch {
+	case update != (db.CarPatch{ID: id, Version: version}):
+		updatedCar, err = h.service.UpdateCar(r.Context(), update)
+	case !strict:
+		err = errs.Validation("nothing to update")
+	default:
+		// an empty merge patch changes nothing, the car is returned as is
+		updatedCar, err = h.service.GetCarByID(r.Context(), id)
+		if err == nil && updatedCar.DeletedAt != nil {
+			err = errs.NotFound("car not found")
+		}
+		if err == nil && version != 0 && version != updatedCar.Version {
+			err = errETagMismatch
+		}
+	}
 	if err != nil {
-		writeErrResponse(w, http.StatusBadRequest, err)
+		writeErrResponse(w, err)
 		return
 	}
 
-	var payload updatePayload
+	w.Header().Set("ETag", carETag(updatedCar))
 
-	err = json.NewDecoder(r.Body).Decode(&payload)
-	
+	writeOkResponse(w, http.StatusOK, updatedCar)
+}
+
+// @Summary ReplaceCar
+// @Tags car
+// @Description replace the whole car, owner included. regNum, mark, model, owner.name and
+// @Description owner.surname are required, an absent year or owner.patronymic is cleared.
+// @Description A PUT mirrors a whole record, so a different owner is taken as the car changing
+// @Description hands: it is recorded and audited as a transfer, PATCH corrects owner data instead
+// @ID replace-car
+// @Accept json
+// @Produce json
+// @Param id path int true "Car ID"
+// @Param request body replacePayload true "the whole car"
+// @Param If-Match header string false "ETag the replacement is based on"
+// @Success 200 {object} HTTPResponse{data=db.Car}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse "regNum is taken"
+// @Failure 412 {object} HTTPResponse "the car was changed since the given version"
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id} [put]
+func (h *Handler) replaceCar(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	car, bodyVersion, err := readReplacement(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	existing, err := h.service.GetCarByID(r.Context(), id)
+	if err == nil && existing.DeletedAt != nil {
+		err = errs.NotFound("car not found")
+	}
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	h.replace(w, r, existing, car, bodyVersion)
+}
+
+// @Summary ReplaceCarByRegNum
+// @Tags car
+// @Description replace the whole car with the regNum like PUT /api/v1/car/{id}. When
+// @Description PUT_CREATE is on a regNum which isn't in the catalogue is created as a new car,
+// @Description If-None-Match: * makes sure an existing car isn't replaced
+// @ID replace-car-by-reg-num
+// @Accept json
+// @Produce json
+// @Param regNum path string true "registration number, the same as in the body"
+// @Param request body replacePayload true "the whole car"
+// @Param If-Match header string false "ETag the replacement is based on"
+// @Param If-None-Match header string false "* to only create the car"
+// @Success 200 {object} HTTPResponse{data=db.Car} "car replaced"
+// @Success 201 {object} HTTPResponse{data=db.Car} "car created"
+// @Header 201 {string} Location "the new car"
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse "the car was created meanwhile"
+// @Failure 412 {object} HTTPResponse "the car was changed since the given version or already exists"
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/by-reg-num/{regNum} [put]
+func (h *Handler) replaceCarByRegNum(w http.ResponseWriter, r *http.Request) {
+	regNum, err := regnum.Parse(chi.URLParam(r, "regNum"))
 	if err != nil {
-		writeErrResponse(w, http.StatusBadRequest, err)
+		writeErrResponse(w, errs.Validation("regNum in the path "+clientMessage(err)))
 		return
 	}
 
+	car, bodyVersion, err := readReplacement(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	if car.RegNum != regNum.RegNum {
+		writeErrResponse(w, errs.InvalidFields([]errs.FieldError{{Field: "regNum", Message: "must be the regNum of the path"}}))
+		return
+	}
+
+	existing, err := h.service.GetCarByRegNum(r.Context(), car.RegNum)
+
+	switch {
+	case errors.Is(err, errs.ErrNotFound) && h.putCreate:
+		// a version can't match a car which isn't there
+		if r.Header.Get("If-Match") != "" || bodyVersion != 0 {
+			writeErrResponse(w, errETagMismatch)
+			return
+		}
+
+		id, _, err := h.service.CreateCar(r.Context(), car, db.ConflictError)
+		if err == nil {
+			car, err = h.service.GetCarByID(r.Context(), id)
+		}
+		if err != nil {
+			writeErrResponse(w, err)
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/v1/car/%d", car.ID))
+		w.Header().Set("ETag", carETag(car))
+
+		writeOkResponse(w, http.StatusCreated, car)
+	case err != nil:
+		writeErrResponse(w, err)
+	case r.Header.Get("If-None-Match") == "*":
+		writeErrResponse(w, errs.PreconditionFailed("car already exists"))
+	default:
+		h.replace(w, r, existing, car, bodyVersion)
+	}
+}
+
+// readReplacement decodes and validates a PUT body into the car it describes
+// and the version given in the body.
+func readReplacement(r *http.Request) (db.Car, int, error) {
+	var payload replacePayload
+
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		return db.Car{}, 0, errs.Validation("invalid request body: " + err.Error())
+	}
+
+	if err = validPayload(&payload); err != nil {
+		return db.Car{}, 0, err
+	}
+
 	car := db.Car{
-		ID: id,
-		RegNum: strings.ToLower(payload.RegNum),
-		Model: strings.ToLower(payload.Model),
-		Mark: strings.ToLower(payload.Mark),
-		Year: payload.Year,
+		RegNum: regnum.Normalize(payload.RegNum),
+		Mark:   strings.ToLower(payload.Mark),
+		Model:  strings.ToLower(payload.Model),
+		Year:   payload.Year,
 		Owner: db.People{
-			Name: strings.ToLower(payload.Name),
-			Surname: strings.ToLower(payload.Surname),
-			Patronymic: strings.ToLower(payload.Patronymic),
+			Name:       strings.ToLower(payload.Owner.Name),
+			Surname:    strings.ToLower(payload.Owner.Surname),
+			Patronymic: strings.ToLower(payload.Owner.Patronymic),
 		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
-	updatedCar, err := h.service.UpdateCar(r.Context(), car)
+	return car, payload.Version, nil
+}
+
+// replace overwrites every field of the existing car with the car from the
+// request, provided the versions from If-Match and the body match it. Unlike
+// PATCH, which fixes the owner's data, a whole record with another owner comes
+// from a registry where the car changed hands, so it is a transfer.
+func (h *Handler) replace(w http.ResponseWriter, r *http.Request, existing, car db.Car, bodyVersion int) {
+	version, err := ifMatchVersion(r, existing.ID)
 	if err != nil {
-		writeErrResponse(w, http.StatusInternalServerError, err)
+		writeErrResponse(w, err)
 		return
 	}
 
-	writeOkResponse(w, http.StatusOK, updatedCar)
+	if bodyVersion != 0 {
+		if version != 0 && version != bodyVersion {
+			writeErrResponse(w, errETagMismatch)
+			return
+		}
+
+		version = bodyVersion
+	}
+
+	car, err = h.service.UpdateCar(r.Context(), db.CarPatch{
+		ID:            existing.ID,
+		Version:       version,
+		RegNum:        &car.RegNum,
+		Mark:          &car.Mark,
+		Model:         &car.Model,
+		Year:          &car.Year,
+		Name:          &car.Owner.Name,
+		Surname:       &car.Owner.Surname,
+		Patronymic:    &car.Owner.Patronymic,
+		OwnerTransfer: true,
+	})
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", carETag(car))
+
+	writeOkResponse(w, http.StatusOK, car)
 }
 
 // @Summary DeleteCar
 // @Tags car
-// @Description delete car
+// @Description soft delete car, it can be restored until the retention job purges it
 // @ID delete-car
 // @Accept json
 // @Produce json
 // @Param id path int true "Car ID"
 // @Success 204 {integer} HTTPResponse
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
 // @Failure 500 {object} HTTPResponse
 // @Failure default {object} HTTPResponse
 // @Router /api/v1/car/{id} [delete]
 func (h *Handler) deleteCar(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	id, err := carID(r)
 	if err != nil {
-		writeErrResponse(w, http.StatusBadRequest, err)
+		writeErrResponse(w, err)
 		return
 	}
 
 	err = h.service.DeleteCar(r.Context(), id)
 	if err != nil {
-		writeErrResponse(w, http.StatusInternalServerError, err)
+		writeErrResponse(w, err)
 		return
 	}
 
 	writeOkResponse(w, http.StatusNoContent, nil)
 }
 
-func writeOkResponse(w http.ResponseWriter, statusCode int, data any) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+// @Summary RestoreCar
+// @Tags car
+// @Description restore a soft deleted car
+// @ID restore-car
+// @Produce json
+// @Param id path int true "Car ID"
+// @Success 200 {object} HTTPResponse{data=db.Car}
+// @Failure 400 {object} HTTPResponse
+// @Failure 404 {object} HTTPResponse
+// @Failure 409 {object} HTTPResponse "the plate was taken by another car"
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/car/{id}/restore [post]
+func (h *Handler) restoreCar(w http.ResponseWriter, r *http.Request) {
+	id, err := carID(r)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
+	}
 
-	if data != nil {
-		err := json.NewEncoder(w).Encode(HTTPResponse{Data: data})
-		if err != nil {
-			log.Error(err)
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	car, err := h.service.RestoreCar(r.Context(), id)
+	if err != nil {
+		writeErrResponse(w, err)
+		return
 	}
-}
 
-func writeErrResponse(w http.ResponseWriter, statusCode int, err error) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-	log.Error(err)
+	writeOkResponse(w, http.StatusOK, car)
+}
 
-	jsonErr := json.NewEncoder(w).Encode(HTTPResponse{Error: err.Error()})
-	if jsonErr != nil {
-		log.Error(jsonErr)
+// @Summary InvalidateInfoCache
+// @Tags car
+// @Description drop the cached info API answer for a regNum
+// @ID invalidate-info-cache
+// @Produce json
+// @Param regNum path string true "registration number"
+// @Success 204
+// @Failure 500 {object} HTTPResponse
+// @Failure default {object} HTTPResponse
+// @Router /api/v1/info-cache/{regNum} [delete]
+func (h *Handler) invalidateInfoCache(w http.ResponseWriter, r *http.Request) {
+	err := h.apiClient.InvalidateCache(r.Context(), regnum.Latin(chi.URLParam(r, "regNum")))
+	if err != nil {
+		writeErrResponse(w, err)
+		return
 	}
-}
\ No newline at end of file
+
+	writeOkResponse(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,254 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/api"
+	"github.com/basedalex/effective-mobile-test/internal/db"
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/regnum"
+	"github.com/go-chi/chi/v5"
+)
+
+// fakeCars is an in-memory carService, methods the tests don't need panic.
+type fakeCars struct {
+	carService
+
+	mu   sync.Mutex
+	cars map[int]db.Car
+}
+
+func newFakeCars(cars ...db.Car) *fakeCars {
+	f := &fakeCars{cars: make(map[int]db.Car)}
+
+	for _, c := range cars {
+		f.cars[c.ID] = c
+	}
+
+	return f
+}
+
+func (f *fakeCars) CreateCar(_ context.Context, c db.Car, _ db.ConflictPolicy) (int, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, existing := range f.cars {
+		if existing.RegNum == c.RegNum {
+			return existing.ID, false, errs.Conflict("car with this regNum already exists")
+		}
+	}
+
+	c.ID = len(f.cars) + 1
+	f.cars[c.ID] = c
+
+	return c.ID, true, nil
+}
+
+func (f *fakeCars) GetCarByID(_ context.Context, id int) (db.Car, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c, ok := f.cars[id]
+	if !ok {
+		return db.Car{}, errs.NotFound("car not found")
+	}
+
+	return c, nil
+}
+
+// infoServer answers /info like the external service. Plates starting with
+// 400 get a 400, plates starting with SLOW hang until the request is canceled,
+// the rest are found after the delay given in the map.
+func infoServer(t *testing.T, delays map[string]time.Duration) *api.Client {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		regNum := r.URL.Query().Get("regNum")
+
+		switch {
+		case strings.HasPrefix(regNum, "B"):
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		case strings.HasPrefix(regNum, "T"):
+			<-r.Context().Done()
+			return
+		}
+
+		select {
+		case <-time.After(delays[regNum]):
+		case <-r.Context().Done():
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(api.Car{
+			RegNum: regNum,
+			Mark:   "Lada",
+			Model:  "Vesta",
+			Year:   2020,
+			Owner:  api.People{Name: "Ivan", Surname: "Ivanov"},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	host, port, err := net.SplitHostPort(u.Host)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return api.New(host, port, api.Options{Timeout: 5 * time.Second})
+}
+
+// withID sets the {id} path parameter the way chi does for a routed request.
+func withID(r *http.Request, id string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func postCars(t *testing.T, h *Handler, body string) (int, []createResult) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/car", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	h.createCar(w, r)
+
+	var res struct {
+		Data []createResult `json:"data"`
+	}
+
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatal(err)
+	}
+
+	return w.Code, res.Data
+}
+
+func TestCreateCar(t *testing.T) {
+	// upstream sees Latin plates, the delays make later plates finish first
+	delays := map[string]time.Duration{
+		"A111AA77": 60 * time.Millisecond,
+		"A222AA77": 0,
+		"A333AA77": 30 * time.Millisecond,
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantCode   int
+		wantStatus []createStatus
+		wantErrors []string
+	}{
+		{
+			name:       "all stored in request order",
+			body:       `{"regNums": ["a111aa77", "A222AA77", "а333аа77"]}`,
+			wantCode:   http.StatusCreated,
+			wantStatus: []createStatus{statusCreated, statusCreated, statusCreated},
+		},
+		{
+			name:       "some stored",
+			body:       `{"regNums": ["A111AA77", "B111BB77", "A111AA 77"]}`,
+			wantCode:   http.StatusMultiStatus,
+			wantStatus: []createStatus{statusCreated, statusEnrichmentFailed, statusDuplicate},
+		},
+		{
+			name:       "none stored",
+			body:       `{"regNums": ["B111BB77", "T111TT77"]}`,
+			wantCode:   http.StatusUnprocessableEntity,
+			wantStatus: []createStatus{statusEnrichmentFailed, statusEnrichmentFailed},
+			wantErrors: []string{"incorrect status code: 400", "info api request timed out"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &Handler{
+				service:            newFakeCars(),
+				apiClient:          infoServer(t, delays),
+				enrichWorkers:      3,
+				enrichCallTimeout:  200 * time.Millisecond,
+				enrichTotalTimeout: 5 * time.Second,
+			}
+
+			code, results := postCars(t, h, tt.body)
+			if code != tt.wantCode {
+				t.Fatalf("status = %d, want %d", code, tt.wantCode)
+			}
+
+			var regNums []string
+			if err := json.Unmarshal([]byte(tt.body), &struct {
+				RegNums *[]string `json:"regNums"`
+			}{&regNums}); err != nil {
+				t.Fatal(err)
+			}
+
+			if len(results) != len(tt.wantStatus) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.wantStatus))
+			}
+
+			for i, res := range results {
+				if want := regnum.Normalize(regNums[i]); res.RegNum != want {
+					t.Errorf("result %d is for %s, want %s", i, res.RegNum, want)
+				}
+
+				if res.Status != tt.wantStatus[i] {
+					t.Errorf("result %d status = %s, want %s (%s)", i, res.Status, tt.wantStatus[i], res.Error)
+				}
+
+				if tt.wantErrors != nil && res.Error != tt.wantErrors[i] {
+					t.Errorf("result %d error = %q, want %q", i, res.Error, tt.wantErrors[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCreateCarTotalDeadline(t *testing.T) {
+	h := &Handler{
+		service:            newFakeCars(),
+		apiClient:          infoServer(t, nil),
+		enrichWorkers:      1,
+		enrichCallTimeout:  100 * time.Millisecond,
+		enrichTotalTimeout: 150 * time.Millisecond,
+	}
+
+	start := time.Now()
+
+	code, results := postCars(t, h, `{"regNums": ["T111TT77", "T222TT77", "T333TT77", "A111AA77"]}`)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("request took %s, the total deadline is 150ms", elapsed)
+	}
+
+	if code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", code, http.StatusUnprocessableEntity)
+	}
+
+	want := []string{
+		"info api request timed out",
+		"info api request timed out",
+		"request deadline exceeded before enrichment",
+		"request deadline exceeded before enrichment",
+	}
+
+	for i, res := range results {
+		if res.Status != statusEnrichmentFailed || res.Error != want[i] {
+			t.Errorf("result %d = %s %q, want %s %q", i, res.Status, res.Error, statusEnrichmentFailed, want[i])
+		}
+	}
+}
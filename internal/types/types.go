@@ -1,13 +1,115 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GetCarQuery filters the car list. Slices match any of their values, empty
+// and zero fields are not filtered on.
 type GetCarQuery struct {
-	RegNum     string `in:"query=regNum"`
-	Mark       string `in:"query=mark"`
-	Model      string `in:"query=model"`
-	Year       int    `in:"query=year"`
-	Name       string `in:"query=name"`
-	Surname    string `in:"query=surname"`
-	Patronymic string `in:"query=patronymic"`
-	Limit      int    `in:"query=limit"`
-	Offset     int    `in:"query=offset"`
-}
\ No newline at end of file
+	RegNum []string `in:"query=regNum" validate:"max=100,dive,regnum"`
+	// Region codes, every code also matches the other codes of its subject
+	Region          []string  `in:"query=region" validate:"max=100,dive,region"`
+	Mark            []string  `in:"query=mark" validate:"max=100,dive,max=100"`
+	Model           []string  `in:"query=model" validate:"max=100,dive,max=100"`
+	ModelPrefix     string    `in:"query=modelPrefix" validate:"max=100"`
+	ModelContains   string    `in:"query=modelContains" validate:"max=100"`
+	Year            []int     `in:"query=year" validate:"max=100,dive,year"`
+	YearFrom        int       `in:"query=yearFrom" validate:"year"`
+	YearTo          int       `in:"query=yearTo" validate:"year"`
+	Name            []string  `in:"query=name" validate:"max=100,dive,max=100"`
+	Surname         []string  `in:"query=surname" validate:"max=100,dive,max=100"`
+	SurnamePrefix   string    `in:"query=surnamePrefix" validate:"max=100"`
+	SurnameContains string    `in:"query=surnameContains" validate:"max=100"`
+	Patronymic      []string  `in:"query=patronymic" validate:"max=100,dive,max=100"`
+	OwnerID         int       `in:"query=ownerId" validate:"min=1"`
+	CreatedAfter    time.Time `in:"query=createdAfter"`
+	CreatedBefore   time.Time `in:"query=createdBefore"`
+	UpdatedAfter    time.Time `in:"query=updatedAfter"`
+	UpdatedBefore   time.Time `in:"query=updatedBefore"`
+	Sort            Sort      `in:"query=sort" validate:"dive"`
+	IncludeDeleted  bool      `in:"query=includeDeleted"`
+	// Cursor is the opaque position returned as nextCursor, it replaces Offset
+	Cursor string `in:"query=cursor" validate:"max=1000"`
+	Limit  int    `in:"query=limit" validate:"min=0,max=1000"`
+	Offset int    `in:"query=offset" validate:"min=0"`
+}
+
+// SortField is one key of a sort=-year,mark parameter.
+type SortField struct {
+	Field string `json:"field" validate:"required,oneof=reg_num mark model year surname created_at updated_at"`
+	Desc  bool   `json:"desc"`
+}
+
+// Sort is a comma separated list of fields, a leading minus means descending
+// order.
+type Sort []SortField
+
+// sortAliases lets clients use the json names of the fields.
+var sortAliases = map[string]string{
+	"regNum":    "reg_num",
+	"createdAt": "created_at",
+	"updatedAt": "updated_at",
+}
+
+func (s *Sort) UnmarshalText(text []byte) error {
+	var (
+		res  Sort
+		seen = map[string]bool{}
+	)
+
+	for _, f := range strings.Split(string(text), ",") {
+		f = strings.TrimSpace(f)
+
+		field := SortField{Field: strings.TrimPrefix(f, "-"), Desc: strings.HasPrefix(f, "-")}
+		if alias, ok := sortAliases[field.Field]; ok {
+			field.Field = alias
+		}
+
+		if seen[field.Field] {
+			return fmt.Errorf("field %q is repeated", field.Field)
+		}
+		seen[field.Field] = true
+
+		res = append(res, field)
+	}
+
+	*s = res
+
+	return nil
+}
+
+type GetOwnerQuery struct {
+	Name       string `in:"query=name" validate:"max=100"`
+	Surname    string `in:"query=surname" validate:"max=100"`
+	Patronymic string `in:"query=patronymic" validate:"max=100"`
+	Limit      int    `in:"query=limit" validate:"min=0,max=1000"`
+	Offset     int    `in:"query=offset" validate:"min=0"`
+}
+
+// PageQuery is the limit and offset of a list without filters.
+type PageQuery struct {
+	Limit  int `in:"query=limit" validate:"min=0,max=1000"`
+	Offset int `in:"query=offset" validate:"min=0"`
+}
+
+// GetOwnershipQuery narrows the ownership history of a car.
+type GetOwnershipQuery struct {
+	// At picks the owner at this moment, zero means the whole chain
+	At time.Time `in:"query=at"`
+}
+
+// GetAuditQuery filters the audit feed.
+type GetAuditQuery struct {
+	Entity    string    `in:"query=entity" validate:"oneof=car owner"`
+	EntityID  int       `in:"query=entityId" validate:"min=1"`
+	Action    []string  `in:"query=action" validate:"max=10,dive,oneof=create update delete restore transfer merge purge"`
+	Actor     string    `in:"query=actor" validate:"max=100"`
+	RequestID string    `in:"query=requestId" validate:"max=100"`
+	From      time.Time `in:"query=from"`
+	To        time.Time `in:"query=to"`
+	Limit     int       `in:"query=limit" validate:"min=0,max=1000"`
+	Offset    int       `in:"query=offset" validate:"min=0"`
+}
@@ -0,0 +1,120 @@
+package validate
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Query fills the fields of dst, a pointer to a struct, from the query
+// parameters named by their in:"query=name" tags. Supported types are
+// strings, ints, bools, times (RFC 3339 or YYYY-MM-DD), encoding.TextUnmarshaler and
+// slices of them, which take every value of a repeated parameter. Values
+// which can't be parsed are returned as field errors.
+func Query(q url.Values, dst any) []errs.FieldError {
+	rv := reflect.ValueOf(dst).Elem()
+	rt := rv.Type()
+
+	var res []errs.FieldError
+
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+
+		name, ok := strings.CutPrefix(f.Tag.Get("in"), "query=")
+		if !ok {
+			continue
+		}
+
+		values := q[name]
+		if len(values) == 0 {
+			continue
+		}
+
+		field := rv.Field(i)
+
+		if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) && !reflect.PointerTo(field.Type()).Implements(textUnmarshalerType) {
+			slice := reflect.MakeSlice(field.Type(), 0, len(values))
+
+			for j, v := range values {
+				elem := reflect.New(field.Type().Elem()).Elem()
+
+				if err := setValue(elem, v); err != nil {
+					res = append(res, errs.FieldError{Field: fmt.Sprintf("%s[%d]", name, j), Message: err.Error()})
+					continue
+				}
+
+				slice = reflect.Append(slice, elem)
+			}
+
+			field.Set(slice)
+
+			continue
+		}
+
+		if len(values) > 1 {
+			res = append(res, errs.FieldError{Field: name, Message: "must not be repeated"})
+			continue
+		}
+
+		if err := setValue(field, values[0]); err != nil {
+			res = append(res, errs.FieldError{Field: name, Message: err.Error()})
+		}
+	}
+
+	return res
+}
+
+func setValue(v reflect.Value, s string) error {
+	if v.Type() == timeType {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, s)
+		}
+		if err != nil {
+			return errors.New("must be an RFC 3339 time or a YYYY-MM-DD date")
+		}
+
+		v.Set(reflect.ValueOf(t))
+
+		return nil
+	}
+
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+
+		v.SetInt(int64(n))
+	default:
+		panic(fmt.Sprintf("validate: can't bind query into %s", v.Type()))
+	}
+
+	return nil
+}
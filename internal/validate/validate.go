@@ -0,0 +1,229 @@
+// Package validate binds query parameters into structs by their in tags and
+// checks structs against the rules of their validate tags.
+//
+// Rules are separated by commas. Zero fields skip the other rules, only
+// required rejects them. For slices min and max apply to the length, and the rules after
+// dive apply to every element. Elements are always checked and empty strings
+// among them are rejected:
+//
+//	required     the value must not be zero, blank strings count as zero
+//	min=N, max=N bounds of an int or of a string length in characters
+//	year         a car production year, 1885 to next year
+//	regnum       a registration number in one of the regnum formats, never empty
+//	region       a known plate region code
+//	oneof=a b c  one of the listed strings
+//	notblank     a non-empty string must contain more than whitespace
+//	dive         the following rules apply to slice elements
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+	"github.com/basedalex/effective-mobile-test/internal/regnum"
+)
+
+// firstCarYear is the year of the first production car.
+const firstCarYear = 1885
+
+// Struct checks v, a struct or a pointer to one, and returns all field errors.
+func Struct(v any) []errs.FieldError {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	var res []errs.FieldError
+
+	checkStruct(rv, "", &res)
+
+	return res
+}
+
+func checkStruct(rv reflect.Value, prefix string, res *[]errs.FieldError) {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		f := rt.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := prefix + fieldName(f)
+		value := rv.Field(i)
+
+		if tag, ok := f.Tag.Lookup("validate"); ok {
+			checkValue(value, name, strings.Split(tag, ","), true, res)
+			continue
+		}
+
+		if value.Kind() == reflect.Pointer && !value.IsNil() {
+			value = value.Elem()
+		}
+
+		if value.Kind() == reflect.Struct && value.Type() != timeType {
+			checkStruct(value, name+".", res)
+		}
+	}
+}
+
+// checkValue applies the rules to v. Fields pass skipZero, so absent
+// optional values aren't checked, slice elements don't.
+func checkValue(v reflect.Value, name string, rules []string, skipZero bool, res *[]errs.FieldError) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if contains(rules, "required") {
+				*res = append(*res, errs.FieldError{Field: name, Message: "is required"})
+			}
+
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	for i, rule := range rules {
+		if rule == "dive" {
+			for j := range v.Len() {
+				elem, elemName := v.Index(j), fmt.Sprintf("%s[%d]", name, j)
+
+				if elem.Kind() == reflect.String && strings.TrimSpace(elem.String()) == "" {
+					*res = append(*res, errs.FieldError{Field: elemName, Message: "must not be empty"})
+					continue
+				}
+
+				checkValue(elem, elemName, rules[i+1:], false, res)
+			}
+
+			return
+		}
+
+		if skipZero && rule != "required" && v.IsZero() {
+			continue
+		}
+
+		if msg := checkRule(v, rule); msg != "" {
+			*res = append(*res, errs.FieldError{Field: name, Message: msg})
+			return
+		}
+	}
+
+	if v.Kind() == reflect.Struct && v.Type() != timeType {
+		checkStruct(v, name+".", res)
+	}
+}
+
+func checkRule(v reflect.Value, rule string) string {
+	key, arg, _ := strings.Cut(rule, "=")
+
+	switch key {
+	case "required":
+		if v.IsZero() || (v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "") {
+			return "is required"
+		}
+	case "notblank":
+		if v.Kind() == reflect.String && strings.TrimSpace(v.String()) == "" {
+			return "must not be blank"
+		}
+	case "min", "max":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			panic(fmt.Sprintf("validate: bad rule %q", rule))
+		}
+
+		return checkBound(v, key, n)
+	case "year":
+		maxYear := time.Now().Year() + 1
+		if y := int(v.Int()); y < firstCarYear || y > maxYear {
+			return fmt.Sprintf("must be between %d and %d", firstCarYear, maxYear)
+		}
+	case "regnum":
+		if v.String() == "" {
+			return "must not be empty"
+		}
+
+		if _, err := regnum.Parse(v.String()); err != nil {
+			msg, _ := errs.Message(err)
+			return msg
+		}
+	case "region":
+		if regnum.RegionName(v.String()) == "" {
+			return "must be a known region code"
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		if !contains(options, v.String()) {
+			return "must be one of " + strings.Join(options, ", ")
+		}
+	default:
+		panic(fmt.Sprintf("validate: unknown rule %q", rule))
+	}
+
+	return ""
+}
+
+func checkBound(v reflect.Value, key string, n int) string {
+	var (
+		got  int
+		what string
+	)
+
+	switch v.Kind() {
+	case reflect.String:
+		got, what = utf8.RuneCountInString(v.String()), " characters"
+	case reflect.Slice:
+		got, what = v.Len(), " items"
+	case reflect.Int, reflect.Int64:
+		got = int(v.Int())
+	default:
+		panic(fmt.Sprintf("validate: %s on %s", key, v.Kind()))
+	}
+
+	if key == "min" && got < n {
+		if what != "" {
+			return fmt.Sprintf("must have at least %d%s", n, what)
+		}
+
+		return fmt.Sprintf("must be at least %d", n)
+	}
+
+	if key == "max" && got > n {
+		if what != "" {
+			return fmt.Sprintf("must have at most %d%s", n, what)
+		}
+
+		return fmt.Sprintf("must be at most %d", n)
+	}
+
+	return ""
+}
+
+// fieldName is the name clients know the field by: the query parameter or
+// the json key, falling back to the Go name.
+func fieldName(f reflect.StructField) string {
+	if in, ok := f.Tag.Lookup("in"); ok {
+		if name, ok := strings.CutPrefix(in, "query="); ok {
+			return name
+		}
+	}
+
+	if js, ok := f.Tag.Lookup("json"); ok {
+		if name, _, _ := strings.Cut(js, ","); name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return f.Name
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
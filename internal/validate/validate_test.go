@@ -0,0 +1,77 @@
+package validate
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/basedalex/effective-mobile-test/internal/errs"
+)
+
+func TestStructEmptyElements(t *testing.T) {
+	type payload struct {
+		RegNums []string `json:"regNums" validate:"required,dive,regnum"`
+		Marks   []string `json:"marks" validate:"dive,max=5"`
+		Years   []int    `json:"years" validate:"dive,year"`
+		RegNum  string   `json:"regNum" validate:"regnum"`
+	}
+
+	tests := []struct {
+		name string
+		in   payload
+		want []errs.FieldError
+	}{
+		{
+			name: "valid",
+			in:   payload{RegNums: []string{"А123ВС77"}, Marks: []string{"lada"}, Years: []int{2020}},
+		},
+		{
+			name: "absent optional regNum is skipped",
+			in:   payload{RegNums: []string{"А123ВС77"}},
+		},
+		{
+			name: "empty regNum element",
+			in:   payload{RegNums: []string{"А123ВС77", ""}},
+			want: []errs.FieldError{{Field: "regNums[1]", Message: "must not be empty"}},
+		},
+		{
+			name: "blank string element",
+			in:   payload{RegNums: []string{"А123ВС77"}, Marks: []string{" "}},
+			want: []errs.FieldError{{Field: "marks[0]", Message: "must not be empty"}},
+		},
+		{
+			name: "zero int element",
+			in:   payload{RegNums: []string{"А123ВС77"}, Years: []int{0}},
+			want: []errs.FieldError{{Field: "years[0]", Message: "must be between 1885 and " + maxYear()}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Struct(&tt.in)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("Struct() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryEmptyRegNum(t *testing.T) {
+	var q struct {
+		RegNum []string `in:"query=regNum" validate:"dive,regnum"`
+	}
+
+	fields := Query(url.Values{"regNum": {""}}, &q)
+	fields = append(fields, Struct(&q)...)
+
+	want := []errs.FieldError{{Field: "regNum[0]", Message: "must not be empty"}}
+	if !reflect.DeepEqual(fields, want) {
+		t.Fatalf("fields = %v, want %v", fields, want)
+	}
+}
+
+func maxYear() string {
+	return strconv.Itoa(time.Now().Year() + 1)
+}